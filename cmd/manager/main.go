@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -16,7 +17,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -36,6 +39,8 @@ var (
 	restart      context.CancelFunc
 	kubeClient   client.Client
 	watchers     []v1alpha1.Watcher
+	// kubeConfigVersions are the resource versions of the kubeconfig secrets of the watchers.
+	kubeConfigVersions map[string]string
 )
 
 func main() {
@@ -48,10 +53,12 @@ func main() {
 		Scheme: scheme,
 	}))
 
-	common.Must(RefreshWatchers(context.Background(), kubeClient))
+	if refreshErr := RefreshWatchers(interruptCtx, kubeClient); refreshErr != nil {
+		logger.Error(refreshErr, "An error occurred while loading watchers, they're loaded on the next refresh.")
+	}
 
 	common.MustReturn(scheduler.NewJob(gocron.DurationJob(config.WatcherRefreshPeriod), gocron.NewTask(func() {
-		hash := common.MustReturn(hashstructure.Hash(watchers, hashstructure.FormatV2, nil))
+		hash := HashWatchers(watchers, kubeConfigVersions)
 
 		if refreshErr := RefreshWatchers(interruptCtx, kubeClient); refreshErr != nil {
 			logger.Error(refreshErr, "An error occurred while refreshing watchers.")
@@ -59,7 +66,7 @@ func main() {
 			return
 		}
 
-		if hash != common.MustReturn(hashstructure.Hash(watchers, hashstructure.FormatV2, nil)) {
+		if hash != HashWatchers(watchers, kubeConfigVersions) {
 			logger.Info("Watchers updated, restarting")
 			restart()
 		}
@@ -75,50 +82,111 @@ func main() {
 	_ = scheduler.Shutdown()
 }
 
-func RefreshWatchers(ctx context.Context, kubeClient client.Reader) error {
+// RefreshWatchers lists and loads the watchers, skipping the ones that can't be loaded or compiled, which is reported
+// in their status.
+func RefreshWatchers(ctx context.Context, kubeClient client.Client) error {
 	watcherList := v1alpha1.WatcherList{}
 	if listErr := kubeClient.List(ctx, &watcherList); listErr != nil {
 		return listErr
 	}
 
+	namespacedWatcherList := v1alpha1.NamespacedWatcherList{}
+	if listErr := kubeClient.List(ctx, &namespacedWatcherList); listErr != nil {
+		return listErr
+	}
+
+	allWatchers := watcherList.Items
+	for _, namespacedWatcher := range namespacedWatcherList.Items {
+		allWatchers = append(allWatchers, namespacedWatcher.AsWatcher())
+	}
+
 	watchers = []v1alpha1.Watcher{}
 
-	for _, watcher := range watcherList.Items {
-		for _, secretKeySelector := range watcher.Spec.ValuesFrom.Secrets {
-			var (
-				secret         v1.Secret
-				specFromSecret v1alpha1.WatcherSpec
-			)
-
-			if getErr := kubeClient.Get(ctx, types.NamespacedName{
-				Name: secretKeySelector.Name, Namespace: secretKeySelector.Namespace,
-			}, &secret); getErr != nil {
-				return getErr
-			}
-
-			if unmarshallErr := yaml.Unmarshal(secret.Data[secretKeySelector.Key],
-				&specFromSecret); unmarshallErr != nil {
-				return unmarshallErr
-			}
-
-			if mergeErr := mergo.Merge(&watcher, v1alpha1.Watcher{Spec: specFromSecret},
-				mergo.WithOverride, mergo.WithAppendSlice); mergeErr != nil {
-				return mergeErr
-			}
+	for _, watcher := range allWatchers {
+		loadErr := LoadWatcher(ctx, kubeClient, &watcher)
+		if loadErr == nil {
+			loadErr = common.Recover(func() { watcher.Compile() })
+		}
+
+		if setErr := pkg.SetLoaded(ctx, kubeClient, &watcher, loadErr); setErr != nil {
+			logger.Error(setErr, "An error occurred while updating the watcher status.", "watcher", watcher.Key())
+		}
+
+		if loadErr != nil {
+			logger.Error(loadErr, "Skipping the watcher, it can't be loaded.", "watcher", watcher.Key())
+
+			continue
 		}
 
 		watchers = append(watchers, watcher)
 	}
 
+	kubeConfigVersions = pkg.KubeConfigVersions(ctx, kubeClient, watchers)
+
 	return nil
 }
 
+// LoadWatcher merges the spec of the watcher from its secrets, restricting namespaced watchers to
+// their namespace both before the secrets are read and after they're merged.
+func LoadWatcher(ctx context.Context, kubeClient client.Reader, watcher *v1alpha1.Watcher) error {
+	if restrictErr := pkg.RestrictToNamespace(watcher); restrictErr != nil {
+		return restrictErr
+	}
+
+	for _, secretKeySelector := range watcher.Spec.ValuesFrom.Secrets {
+		var (
+			secret         v1.Secret
+			specFromSecret v1alpha1.WatcherSpec
+		)
+
+		if getErr := kubeClient.Get(ctx, types.NamespacedName{
+			Name: secretKeySelector.Name, Namespace: secretKeySelector.Namespace,
+		}, &secret); getErr != nil {
+			return getErr
+		}
+
+		if unmarshallErr := yaml.Unmarshal(secret.Data[secretKeySelector.Key],
+			&specFromSecret); unmarshallErr != nil {
+			return unmarshallErr
+		}
+
+		if mergeErr := mergo.Merge(watcher, v1alpha1.Watcher{Spec: specFromSecret},
+			mergo.WithOverride, mergo.WithAppendSlice); mergeErr != nil {
+			return mergeErr
+		}
+	}
+
+	return pkg.RestrictToNamespace(watcher)
+}
+
+// HashWatchers hashes only the specs of the watchers and the versions of their kubeconfigs, so status updates don't
+// restart the manager while rotated kubeconfigs do.
+func HashWatchers(watchers []v1alpha1.Watcher, kubeConfigVersions map[string]string) uint64 {
+	specs := make(map[string]v1alpha1.WatcherSpec, len(watchers))
+	for _, watcher := range watchers {
+		specs[client.ObjectKeyFromObject(&watcher).String()] = watcher.Spec
+	}
+
+	return common.MustReturn(hashstructure.Hash(struct {
+		Specs              map[string]v1alpha1.WatcherSpec
+		KubeConfigVersions map[string]string
+	}{specs, kubeConfigVersions}, hashstructure.FormatV2, nil))
+}
+
 func StartManager(ctx context.Context, watchers []v1alpha1.Watcher) {
-	manager := common.MustReturn(ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	var (
+		restConfig      = ctrl.GetConfigOrDie()
+		discoveryClient = common.MustReturn(discovery.NewDiscoveryClientForConfig(restConfig))
+	)
+
+	compiledWatchers, clusters, resolver := CompileWatchers(ctx, restConfig, discoveryClient, watchers)
+
+	manager := common.MustReturn(ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme: scheme,
 		Logger: logger,
 		Cache: cache.Options{
 			SyncPeriod: &config.SyncPeriod,
+			ByObject:   pkg.NewCacheByObject(compiledWatchers, discoveryClient),
 		},
 		Metrics: server.Options{
 			BindAddress: fmt.Sprintf(":%d", metricPort),
@@ -129,11 +197,83 @@ func StartManager(ctx context.Context, watchers []v1alpha1.Watcher) {
 		LeaderElectionReleaseOnCancel: true,
 	}))
 
-	for _, watcher := range watchers {
-		common.Must(pkg.NewController(manager.GetClient(), &http.Client{}, watcher.Compile()).SetupWithManager(manager))
+	for key, remote := range clusters {
+		if setupErr := remote.Setup(manager, cache.Options{SyncPeriod: &config.SyncPeriod},
+			compiledWatchers); setupErr != nil {
+			logger.Error(setupErr, "An error occurred while setting up the remote cluster.", "cluster", key)
+		}
+	}
+
+	var (
+		rateLimiters = pkg.NewRateLimiters()
+		valuesLoader = common.MustReturn(pkg.NewValuesLoader(ctx, kubeClient, manager, compiledWatchers))
+		relatedCache = common.MustReturn(pkg.NewRelatedCache(manager, &config.SyncPeriod))
+	)
+
+	for _, watcher := range compiledWatchers {
+		setupErr := pkg.NewController(manager.GetClient(), &http.Client{}, watcher).WithClusters(clusters).
+			WithRateLimiters(rateLimiters).WithValues(valuesLoader.ValuesOf(watcher)).
+			WithRelatedCache(relatedCache).
+			WithDiscovery(discoveryClient, config.SourceDiscoveryPeriod).WithLookupKinds(config.LookupKinds).
+			SetupWithManager(manager)
+		if errors.Is(setupErr, pkg.ErrClusterNotFound) {
+			logger.Error(setupErr, "Skipping the sources of the watcher, their remote clusters aren't available.",
+				"watcher", watcher.Key())
+
+			continue
+		}
+
+		common.Must(setupErr)
 	}
 
+	common.Must(manager.Add(resolver))
+	common.Must(manager.Add(valuesLoader))
+	common.Must(manager.Add(relatedCache))
 	common.Must(manager.AddHealthzCheck("healthz", healthz.Ping))
 	common.Must(manager.AddReadyzCheck("readyz", healthz.Ping))
 	common.Must(manager.Start(ctx))
 }
+
+// CompileWatchers compiles the watchers, reads the kubeconfigs of their remote clusters, impersonates their service
+// accounts and resolves their sources, leaving out the watchers whose sources can't be resolved. The returned resolver
+// restarts the manager once the kinds of the wildcard sources change, so the watchers whose wildcards don't match any
+// kind yet are started once they do.
+func CompileWatchers(ctx context.Context, restConfig *rest.Config, discoveryClient discovery.ServerResourcesInterface,
+	watchers []v1alpha1.Watcher,
+) ([]*v1alpha1.Watcher, map[string]*pkg.RemoteCluster, *pkg.SourcesResolver) {
+	compiledWatchers := make([]*v1alpha1.Watcher, 0, len(watchers))
+	for _, watcher := range watchers {
+		compiledWatchers = append(compiledWatchers, watcher.Compile())
+	}
+
+	clusters, clustersErr := pkg.NewRemoteClusters(ctx, kubeClient, restConfig, compiledWatchers)
+	if clustersErr != nil {
+		logger.Error(clustersErr, "An error occurred while reading the kubeconfigs of the remote clusters.")
+	}
+
+	var (
+		resolver = pkg.NewSourcesResolver(discoveryClient, clusters, compiledWatchers, config.SourceDiscoveryPeriod,
+			restart)
+		resolvedWatchers = make([]*v1alpha1.Watcher, 0, len(compiledWatchers))
+	)
+
+	for _, watcher := range compiledWatchers {
+		resolveErr := pkg.ResolveSources(discoveryClient, clusters, watcher)
+		if errors.Is(resolveErr, pkg.ErrNoSources) {
+			logger.Info("The watcher is pending until its wildcard sources match a kind.", "watcher", watcher.Key())
+
+			continue
+		}
+
+		if resolveErr != nil {
+			logger.Error(resolveErr, "An error occurred while resolving the sources of the watcher.",
+				"watcher", watcher.Key())
+
+			continue
+		}
+
+		resolvedWatchers = append(resolvedWatchers, watcher)
+	}
+
+	return resolvedWatchers, clusters, resolver
+}
@@ -0,0 +1,210 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EventAggregator aggregates the occurrences of the same event within a window, so the first one is sent right away
+// and the repeats are sent together once the window ends.
+type EventAggregator struct {
+	window     time.Duration
+	aggregates map[string]*eventAggregate
+	pruned     time.Time
+	mutex      sync.Mutex
+}
+
+// staleAggregateWindows is how many windows the pending occurrences of an event are kept without being added to,
+// after which the event is assumed to be deleted before they could be sent.
+const staleAggregateWindows = 3
+
+type eventAggregate struct {
+	sent    time.Time
+	added   time.Time
+	pending int
+}
+
+func NewEventAggregator(window time.Duration) *EventAggregator {
+	return &EventAggregator{
+		window:     window,
+		aggregates: map[string]*eventAggregate{},
+	}
+}
+
+// Add adds the occurrences of the event, returning how many occurrences to send now, or how long to wait until the
+// end of the window before they're sent.
+func (a *EventAggregator) Add(key string, occurrences int, now time.Time) (int, time.Duration) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.prune(now)
+
+	aggregate := a.aggregate(key)
+	aggregate.pending += occurrences
+	aggregate.added = now
+
+	if aggregate.pending == 0 {
+		return 0, 0
+	}
+
+	if wait := aggregate.sent.Add(a.window).Sub(now); wait > 0 {
+		return 0, wait
+	}
+
+	count := aggregate.pending
+	aggregate.sent, aggregate.pending = now, 0
+
+	return count, 0
+}
+
+// Restore adds back the occurrences that couldn't be sent, so they're sent right away on the next try.
+func (a *EventAggregator) Restore(key string, occurrences int) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	aggregate := a.aggregate(key)
+	aggregate.sent, aggregate.pending = time.Time{}, aggregate.pending+occurrences
+}
+
+func (a *EventAggregator) aggregate(key string) *eventAggregate {
+	aggregate, found := a.aggregates[key]
+	if !found {
+		aggregate = &eventAggregate{}
+		a.aggregates[key] = aggregate
+	}
+
+	return aggregate
+}
+
+// prune removes the aggregates of the events that weren't repeated within their last window, and the pending ones
+// that weren't added to for staleAggregateWindows, like the ones of the events deleted before they were sent.
+func (a *EventAggregator) prune(now time.Time) {
+	if now.Sub(a.pruned) < a.window {
+		return
+	}
+
+	for key, aggregate := range a.aggregates {
+		if (aggregate.pending == 0 && now.Sub(aggregate.sent) >= a.window) ||
+			now.Sub(aggregate.added) >= staleAggregateWindows*a.window {
+			delete(a.aggregates, key)
+		}
+	}
+
+	a.pruned = now
+}
+
+func newEventAggregator(source *v1alpha1.Source) *EventAggregator {
+	if source.Events == nil {
+		return nil
+	}
+
+	return NewEventAggregator(source.Events.Compiled.AggregationWindow)
+}
+
+// aggregateEvent adds the new occurrences of the event to its aggregate, returning whether they're sent now or how
+// long to wait until they are. The occurrences and the object that the event is about are added to the data.
+func (r *Controller) aggregateEvent(ctx context.Context, obj *unstructured.Unstructured, objectEvent *ObjectEvent,
+	data TemplateData,
+) (bool, time.Duration, error) {
+	if r.aggregator == nil {
+		return true, 0, nil
+	}
+
+	occurrences := 0
+	if objectEvent != nil {
+		occurrences = max(eventCount(obj)-eventCount(objectEvent.OldObject), 0)
+	}
+
+	key := eventKey(obj)
+
+	count, wait := r.aggregator.Add(key, occurrences, time.Now())
+	if count == 0 {
+		return false, wait, nil
+	}
+
+	if involvedErr := r.addInvolvedObject(ctx, obj, data); involvedErr != nil {
+		r.aggregator.Restore(key, count)
+
+		return false, 0, involvedErr
+	}
+
+	data[TemplateKeyOccurrences] = count
+
+	return true, 0, nil
+}
+
+// restoreEvent adds back the occurrences of the event that couldn't be sent.
+func (r *Controller) restoreEvent(obj *unstructured.Unstructured, data TemplateData) {
+	if count, isCount := data[TemplateKeyOccurrences].(int); isCount && r.aggregator != nil {
+		r.aggregator.Restore(eventKey(obj), count)
+	}
+}
+
+// addInvolvedObject fetches the object that the event is about and adds it to the data, unless it doesn't exist or
+// it's outside of the namespace of a namespaced watcher.
+func (r *Controller) addInvolvedObject(ctx context.Context, obj *unstructured.Unstructured, data TemplateData) error {
+	reference := eventReference(obj)
+	if reference["kind"] == "" || reference["name"] == "" ||
+		(r.watcher.IsNamespaced() && reference["namespace"] != r.watcher.GetNamespace()) {
+		return nil
+	}
+
+	involvedObject := &unstructured.Unstructured{}
+	involvedObject.SetAPIVersion(reference["apiVersion"])
+	involvedObject.SetKind(reference["kind"])
+
+	if getErr := r.client.Get(ctx, client.ObjectKey{
+		Namespace: reference["namespace"], Name: reference["name"],
+	}, involvedObject); getErr != nil {
+		return client.IgnoreNotFound(getErr)
+	}
+
+	data[TemplateKeyInvolvedObject] = involvedObject.Object
+
+	return nil
+}
+
+// eventReference returns the reference to the object that the event is about, which is in involvedObject for v1
+// and in regarding for events.k8s.io/v1.
+func eventReference(obj *unstructured.Unstructured) map[string]string {
+	reference, found, _ := unstructured.NestedStringMap(obj.Object, "involvedObject")
+	if !found {
+		reference, _, _ = unstructured.NestedStringMap(obj.Object, "regarding")
+	}
+
+	return reference
+}
+
+// eventKey identifies the repeats of the same event by the object that it's about, its type and reason.
+func eventKey(obj *unstructured.Unstructured) string {
+	var (
+		reference    = eventReference(obj)
+		eventType, _ = obj.Object["type"].(string)
+		reason, _    = obj.Object["reason"].(string)
+	)
+
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s", reference["apiVersion"], reference["kind"], reference["namespace"],
+		reference["name"], eventType, reason)
+}
+
+// eventCount is how many times the event occurred, which is counted in series.count for events.k8s.io/v1 and in
+// count for v1.
+func eventCount(obj *unstructured.Unstructured) int {
+	if obj == nil {
+		return 0
+	}
+
+	for _, path := range [][]string{{"series", "count"}, {"count"}, {"deprecatedCount"}} {
+		if count, found, _ := unstructured.NestedInt64(obj.Object, path...); found && count > 0 {
+			return int(count)
+		}
+	}
+
+	return 1
+}
@@ -0,0 +1,164 @@
+package pkg
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestEventAggregator_Add(t *testing.T) {
+	// given
+	var (
+		aggregator = NewEventAggregator(time.Minute)
+		start      = time.Now()
+	)
+
+	// when
+	firstCount, firstWait := aggregator.Add("my-event", 1, start)
+	repeatCount, repeatWait := aggregator.Add("my-event", 2, start.Add(10*time.Second))
+	otherCount, _ := aggregator.Add("my-other-event", 1, start.Add(10*time.Second))
+	windowCount, windowWait := aggregator.Add("my-event", 0, start.Add(time.Minute))
+	quietCount, quietWait := aggregator.Add("my-event", 0, start.Add(2*time.Minute))
+	aggregator.Add("my-deleted-event", 1, start.Add(2*time.Minute))
+	aggregator.Add("my-deleted-event", 1, start.Add(2*time.Minute+time.Second))
+	aggregator.Add("my-event", 0, start.Add(6*time.Minute))
+
+	// then
+	assert.Equal(t, 1, firstCount)
+	assert.Zero(t, firstWait)
+	assert.Zero(t, repeatCount)
+	assert.Equal(t, 50*time.Second, repeatWait)
+	assert.Equal(t, 1, otherCount)
+	assert.Equal(t, 2, windowCount)
+	assert.Zero(t, windowWait)
+	assert.Zero(t, quietCount)
+	assert.Zero(t, quietWait)
+	assert.NotContains(t, aggregator.aggregates, "my-other-event")
+	assert.NotContains(t, aggregator.aggregates, "my-deleted-event")
+}
+
+func TestEventAggregator_Restore(t *testing.T) {
+	// given
+	var (
+		aggregator = NewEventAggregator(time.Minute)
+		start      = time.Now()
+	)
+	count, _ := aggregator.Add("my-event", 3, start)
+
+	// when
+	aggregator.Restore("my-event", count)
+	retryCount, retryWait := aggregator.Add("my-event", 0, start.Add(time.Second))
+
+	// then
+	assert.Equal(t, 3, retryCount)
+	assert.Zero(t, retryWait)
+}
+
+func TestEventCount(t *testing.T) {
+	for name, testCase := range map[string]struct {
+		object map[string]interface{}
+		count  int
+	}{
+		"core event":        {object: map[string]interface{}{"count": int64(4)}, count: 4},
+		"events.k8s.io":     {object: map[string]interface{}{"series": map[string]interface{}{"count": int64(7)}}, count: 7},
+		"deprecated count":  {object: map[string]interface{}{"deprecatedCount": int64(2)}, count: 2},
+		"single occurrence": {object: map[string]interface{}{}, count: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// when
+			count := eventCount(&unstructured.Unstructured{Object: testCase.object})
+
+			// then
+			assert.Equal(t, testCase.count, count)
+		})
+	}
+}
+
+func TestController_Reconcile_Events(t *testing.T) {
+	// given
+	var (
+		bodies []string
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			content, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(content))
+		}))
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{
+					APIVersion: "v1",
+					Kind:       "Event",
+					Events:     &v1alpha1.EventsSource{AggregationWindow: ptr.To("1m")},
+				},
+				Destination: v1alpha1.Destination{
+					URLTemplate:  server.URL,
+					Method:       "POST",
+					BodyTemplate: `{{ .Occurrences }}/{{ .reason }}/{{ .InvolvedObject.spec.nodeName }}`,
+				},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		controller = NewController(mockClient, server.Client(), watcher)
+		eventName  = types.NamespacedName{Namespace: "default", Name: "my-pod.1"}
+		podName    = types.NamespacedName{Namespace: "default", Name: "my-pod"}
+		newEvent   = func(count int64) *unstructured.Unstructured {
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Event",
+				"metadata":   map[string]interface{}{"name": eventName.Name, "namespace": eventName.Namespace},
+				"involvedObject": map[string]interface{}{
+					"apiVersion": "v1", "kind": "Pod", "namespace": podName.Namespace, "name": podName.Name,
+				},
+				"type":   "Warning",
+				"reason": "OOMKilling",
+				"count":  count,
+			}}
+		}
+		event = newEvent(1)
+	)
+	defer server.Close()
+	mockClient.EXPECT().Get(mock.Anything, eventName, mock.AnythingOfType("*unstructured.Unstructured")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			event.DeepCopyInto(obj.(*unstructured.Unstructured))
+
+			return nil
+		})
+	mockClient.EXPECT().Get(mock.Anything, podName, mock.AnythingOfType("*unstructured.Unstructured")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			assert.Equal(t, "Pod", obj.GetObjectKind().GroupVersionKind().Kind)
+			_ = unstructured.SetNestedField(obj.(*unstructured.Unstructured).Object, "my-node", "spec", "nodeName")
+
+			return nil
+		})
+
+	// when
+	controller.recordEvent(event, nil, EventTypeCreate)
+	_, firstErr := controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: eventName})
+
+	oldEvent := event
+	event = newEvent(3)
+	controller.recordEvent(event, oldEvent, EventTypeUpdate)
+	repeatResult, repeatErr := controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: eventName})
+
+	controller.aggregator.aggregates[eventKey(event)].sent = time.Now().Add(-time.Minute)
+	_, windowErr := controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: eventName})
+
+	// then
+	assert.Nil(t, firstErr)
+	assert.Nil(t, repeatErr)
+	assert.Positive(t, repeatResult.RequeueAfter)
+	assert.Nil(t, windowErr)
+	assert.Equal(t, []string{"1/OOMKilling/my-node", "2/OOMKilling/my-node"}, bodies)
+}
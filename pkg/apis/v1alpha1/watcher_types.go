@@ -1,23 +1,73 @@
 package v1alpha1
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
 	"github.com/nccloud/watchtower/pkg/common"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DestinationTypeHTTP          DestinationType = "http"
+	DestinationTypeFile          DestinationType = "file"
+	DestinationTypeStdout        DestinationType = "stdout"
+	DestinationTypeElasticsearch DestinationType = "elasticsearch"
+
+	SourceKindAll = "*"
+
+	KindWatcher           = "Watcher"
+	KindNamespacedWatcher = "NamespacedWatcher"
+
+	DefaultFileMaxBackups          = 5
+	DefaultBodyTemplate            = "{{ toJson . }}"
+	DefaultElasticsearchIDTemplate = "{{ .metadata.uid }}"
+	DefaultBatchMaxItems           = 100
+	DefaultDebounceMaxWaitFactor   = 10
+	DefaultBatchMaxWait            = time.Second
+	DefaultBatchTemplate           = "[{{ join \",\" .items }}]"
+
+	DiffFormatJSONPatch  DiffFormat = "jsonPatch"
+	DiffFormatMergePatch DiffFormat = "mergePatch"
+
+	DefaultDiffBodyTemplate = "{{ .Patch }}"
+
+	DefaultCircuitBreakerFailureThreshold = 5
+	DefaultCircuitBreakerOpenDuration     = 30 * time.Second
+
+	ConditionTypeDestinationAvailable = "DestinationAvailable"
+	ConditionTypeSourcesAvailable     = "SourcesAvailable"
+	ConditionTypeClustersReachable    = "ClustersReachable"
+	ConditionTypeLoaded               = "Loaded"
+)
+
+var (
+	ErrDestinationOptionsMissing = errors.New("destination options are missing")
+	ErrRateLimitInvalid          = errors.New("rate limit is invalid")
 )
 
 //+kubebuilder:object:root=true
 //+kubebuilder:resource:scope=Cluster
+//+kubebuilder:subresource:status
 
 type Watcher struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec WatcherSpec `json:"spec,omitempty"`
+	Spec   WatcherSpec   `json:"spec,omitempty"`
+	Status WatcherStatus `json:"status,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -29,26 +79,109 @@ type WatcherList struct {
 	Items []Watcher `json:"items"`
 }
 
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Namespaced
+//+kubebuilder:subresource:status
+
+// NamespacedWatcher is a Watcher that is restricted to the sources and secrets in its own namespace, so it can be
+// created without cluster-wide permissions.
+type NamespacedWatcher struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WatcherSpec   `json:"spec,omitempty"`
+	Status WatcherStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+type NamespacedWatcherList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NamespacedWatcher `json:"items"`
+}
+
 type WatcherSpec struct {
 	// Source defines the source objects of the watching process.
 	Source Source `json:"source,omitempty" yaml:"source"`
+	// Sources define more source objects of the watching process with the same filters and destination.
+	// Each of them is watched by its own controller and their objects can be told apart by .kind in templates.
+	Sources []Source `json:"sources,omitempty" yaml:"sources"`
 	// Filter helps filter objects during the watching process.
 	Filter Filter `json:"filter,omitempty" yaml:"filter"`
 	// Destination sets where the rendered objects will be sent.
 	Destination Destination `json:"destination,omitempty" yaml:"destination"`
 	// ValuesFrom allows merging variables from references.
 	ValuesFrom ValuesFrom `json:"valuesFrom,omitempty"`
+	// Values are the variables like a shared endpoint or channel name that are available in templates as .Values.
+	// They override the values of the config maps in valuesFrom.
+	Values map[string]string `json:"values,omitempty" yaml:"values"`
+	// ServiceAccountName is the service account that the sources are watched and the objects are read as, so the
+	// watcher can only see what the service account can. It's in the namespace of a NamespacedWatcher, and in the
+	// namespace/name format for a Watcher. By default, It's the service account of Watchtower.
+	ServiceAccountName *string `json:"serviceAccountName,omitempty" yaml:"serviceAccountName"`
+	Compiled           struct {
+		Sources []Source
+	} `json:"-"`
+}
+
+type WatcherStatus struct {
+	// Conditions are the latest observations of the watcher like the availability of its destination.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 type Source struct {
 	// APIVersion is api version of the object like apps/v1, v1 etc.
 	APIVersion string `json:"apiVersion,omitempty" yaml:"apiVersion"`
-	// Kind is the kind of the object like Deployment, Secret, MyCustomResource etc.
+	// Kind is the kind of the object like Deployment, Secret, MyCustomResource etc. It can be * to watch all kinds
+	// of the api version that can be listed and watched, which are resolved by discovery.
 	Kind string `json:"kind,omitempty" yaml:"kind"`
+	// Namespaces are the namespaces that the objects will be watched in. When all watchers of the same kind
+	// set them, only the objects in their namespaces are cached. By default, It's all namespaces.
+	Namespaces []string `json:"namespaces,omitempty" yaml:"namespaces"`
+	// FieldSelector is the field selector like status.phase=Running to filter objects by the API server.
+	// When all watchers of the same kind share it, only the matching objects are cached.
+	FieldSelector *string `json:"fieldSelector,omitempty" yaml:"fieldSelector"`
 	// Concurrency is how many concurrent workers will be working on processing this source.
 	Concurrency *int `json:"concurrency,omitempty" yaml:"concurrency"`
+	// MetadataOnly watches and caches only the metadata of the objects. The full objects are fetched from the
+	// API server only when the filters or templates may read more than apiVersion, kind and metadata.
+	// By default, It's false.
+	MetadataOnly bool `json:"metadataOnly,omitempty" yaml:"metadataOnly"`
+	// Cluster is the remote cluster that the objects will be watched in. By default, It's the cluster that
+	// Watchtower runs in.
+	Cluster *ClusterSource `json:"cluster,omitempty" yaml:"cluster"`
+	// Events makes the source watch Events of v1 or events.k8s.io/v1, sending their occurrences instead of every
+	// count update and fetching the object that they're about. By default, It's not set.
+	Events *EventsSource `json:"events,omitempty" yaml:"events"`
 	// Options allows you to set source specific options
-	Options SourceOptions `json:"options,omitempty" yaml:"options"`
+	Options  SourceOptions `json:"options,omitempty" yaml:"options"`
+	Compiled struct {
+		FieldSelector fields.Selector
+	} `json:"-"`
+}
+
+type ClusterSource struct {
+	// Name is the name of the cluster that is available in templates as .cluster.
+	// By default, It's the name of the kubeconfig secret.
+	Name *string `json:"name,omitempty" yaml:"name"`
+	// KubeConfig is the reference to the secret key containing the kubeconfig of the cluster.
+	KubeConfig SecretKeySelector `json:"kubeConfig" yaml:"kubeConfig"`
+}
+
+type EventsSource struct {
+	// AggregationWindow is the duration like 5m that the repeats of the same event are aggregated in. The first
+	// occurrence is sent right away and the repeats within the window are sent once it ends, with their count in
+	// templates as .Occurrences. The events of the same object, type and reason are the same event.
+	// By default, It's not set, so every repeat is sent.
+	// +kubebuilder:validation:Format=duration
+	AggregationWindow *string `json:"aggregationWindow,omitempty" yaml:"aggregationWindow"`
+	Compiled          struct {
+		AggregationWindow time.Duration
+	} `json:"-"`
 }
 
 type SourceOptions struct {
@@ -73,11 +206,43 @@ type EventFilter struct {
 	Create CreateEventFilter `json:"create,omitempty" yaml:"create"`
 	// Update allows you to set update event based filters
 	Update UpdateEventFilter `json:"update,omitempty" yaml:"update"`
+	// Debounce is the duration like 10s that the repeated events of the same object are collapsed into
+	// a single delivery of its latest state, once no more events are received within it. By default, It's not set.
+	// +kubebuilder:validation:Format=duration
+	Debounce *string `json:"debounce,omitempty" yaml:"debounce"`
+	// DebounceMaxWait is the maximum duration like 5m that the events of the same object are collapsed for, so that
+	// the objects changing more often than the debounce are still sent. By default, It's ten times the debounce.
+	// +kubebuilder:validation:Format=duration
+	DebounceMaxWait *string `json:"debounceMaxWait,omitempty" yaml:"debounceMaxWait"`
+	// ConditionTransition allows you to send objects only when one of their status conditions changes its status.
+	// When it's set, create events are not sent and the transition is available in templates as .Transition.
+	ConditionTransition *ConditionTransitionFilter `json:"conditionTransition,omitempty" yaml:"conditionTransition"`
+	Compiled            struct {
+		Debounce        time.Duration
+		DebounceMaxWait time.Duration
+	} `json:"-"`
+}
+
+type ConditionTransitionFilter struct {
+	// Type is the type of the condition like Ready, Available etc.
+	Type string `json:"type,omitempty" yaml:"type"`
+	// From is the status like True, False or Unknown that the condition should be changed from.
+	// It's empty when the condition didn't exist before. By default, It's any status.
+	From *string `json:"from,omitempty" yaml:"from"`
+	// To is the status like True, False or Unknown that the condition should be changed to.
+	// By default, It's any status.
+	To *string `json:"to,omitempty" yaml:"to"`
+	// Reason is the regular expression to filter the new reason of the condition.
+	Reason   *string `json:"reason,omitempty" yaml:"reason"`
+	Compiled struct {
+		Reason *regexp.Regexp
+	} `json:"-"`
 }
 
 type CreateEventFilter struct {
 	// CreationTimeout sets what will be the maximum duration can past for the objects in create queue.
 	// It also helps to minimize number of object that will be re-sent when application restarts.
+	// +kubebuilder:validation:Format=duration
 	CreationTimeout *string `json:"creationTimeout,omitempty" yaml:"creationTimeout"`
 	Compiled        struct {
 		CreationTimeout time.Duration
@@ -93,6 +258,18 @@ type UpdateEventFilter struct {
 	// It's useful when you don't want to re-send objects if their resource version is not changed,
 	// like it will happen on full re-synchronization. By default, It's not set.
 	ResourceVersionChanged *bool `json:"resourceVersionChanged,omitempty" yaml:"resourceVersion"`
+	// FieldsChanged are the field paths like status.phase or spec.template.spec.containers[*].image that at least
+	// one of them should be changed. Re-synchronizations keeping the resource version are still sent.
+	// By default, It's not set.
+	FieldsChanged []string `json:"fieldsChanged,omitempty" yaml:"fieldsChanged"`
+	// IgnoreFields are the field paths like metadata.managedFields, status.conditions[*].lastHeartbeatTime or
+	// spec.containers[0] that will not be considered as changes. When it's set, updates that change nothing but these
+	// fields and the resource version are not sent, while re-synchronizations are. By default, It's not set.
+	IgnoreFields []string `json:"ignoreFields,omitempty" yaml:"ignoreFields"`
+	Compiled     struct {
+		FieldsChanged [][]string
+		IgnoreFields  [][]string
+	} `json:"-"`
 }
 
 type ObjectFilter struct {
@@ -104,11 +281,84 @@ type ObjectFilter struct {
 	Labels *map[string]string `json:"labels,omitempty" yaml:"labels"`
 	// Annotations are the labels to filter object by annotation.
 	Annotations *map[string]string `json:"annotations,omitempty" yaml:"annotations"`
+	// LabelSelector is the label selector to filter object by labels with In, NotIn, Exists and DoesNotExist.
+	// When all watchers of the same kind select the same labels, It's also applied to the cache,
+	// so the other objects are never cached.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty" yaml:"labelSelector"`
+	// AnnotationSelector is the label selector to filter object by annotations with In, NotIn, Exists and
+	// DoesNotExist.
+	AnnotationSelector *metav1.LabelSelector `json:"annotationSelector,omitempty" yaml:"annotationSelector"`
+	// NamespaceSelector is the label selector to filter object by the labels of Its namespace.
+	// Objects are processed again when the labels of their namespace start matching and cluster scoped objects never
+	// match. The service account of the watcher needs to get the namespaces, and to list and watch them for the objects
+	// to be processed again, otherwise they're matched again on the next resync.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty" yaml:"namespaceSelector"`
+	// Owner allows you to filter object by Its owner references.
+	Owner *OwnerFilter `json:"owner,omitempty" yaml:"owner"`
+	// ReferencedBy allows you to filter object by the objects in Its namespace referencing It by name,
+	// like the secrets of a service account.
+	ReferencedBy *ReferenceFilter `json:"referencedBy,omitempty" yaml:"referencedBy"`
+	// CEL is the CEL expression that should evaluate to true for the object to be processed, like
+	// object.status.phase == "Running" && eventType == "update". The object, oldObject and eventType
+	// variables are available and oldObject is only set for update events, so it can be checked with
+	// oldObject != null. The expressions failing while reading the fields of a missing oldObject don't match the
+	// object, the other evaluation errors are returned.
+	CEL *string `json:"cel,omitempty" yaml:"cel"`
 	// Custom is the most advanced way of filtering object by their contents and multiple fields by templating.
-	Custom   *CustomObjectFilter `json:"custom,omitempty" yaml:"custom"`
+	Custom *CustomObjectFilter `json:"custom,omitempty" yaml:"custom"`
+	// AllOf are the object filters that all of them should match the object as well.
+	// +kubebuilder:validation:items:Type=object
+	// +kubebuilder:validation:items:XPreserveUnknownFields
+	AllOf []ObjectFilter `json:"allOf,omitempty" yaml:"allOf"`
+	// AnyOf are the object filters that at least one of them should match the object as well.
+	// +kubebuilder:validation:items:Type=object
+	// +kubebuilder:validation:items:XPreserveUnknownFields
+	AnyOf []ObjectFilter `json:"anyOf,omitempty" yaml:"anyOf"`
+	// Not is the object filter that shouldn't match the object.
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Not      *ObjectFilter `json:"not,omitempty" yaml:"not"`
 	Compiled struct {
-		Name      *regexp.Regexp
-		Namespace *regexp.Regexp
+		Name               *regexp.Regexp
+		Namespace          *regexp.Regexp
+		LabelSelector      labels.Selector
+		AnnotationSelector labels.Selector
+		NamespaceSelector  labels.Selector
+		CEL                *common.CELProgram
+	} `json:"-"`
+}
+
+type OwnerFilter struct {
+	// Kind is the kind of the owner like ReplicaSet, Job etc. It can be suffixed with the API group like Job.batch,
+	// so that the kinds with the same name in the other groups don't match. By default, It's any kind.
+	Kind string `json:"kind,omitempty" yaml:"kind"`
+	// Name is the regular expression to filter the owner Its name.
+	Name *string `json:"name,omitempty" yaml:"name"`
+	// Controller sets if only the managing controller of the object should be considered as Its owner.
+	Controller bool `json:"controller,omitempty" yaml:"controller"`
+	// Recursive sets if the owners of the owners should be fetched and matched as well,
+	// like the CronJob owning the Job of a Pod.
+	Recursive bool `json:"recursive,omitempty" yaml:"recursive"`
+	Compiled  struct {
+		Kind schema.GroupKind
+		Name *regexp.Regexp
+	} `json:"-"`
+}
+
+type ReferenceFilter struct {
+	// APIVersion is api version of the referencing objects like v1.
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	// Kind is the kind of the referencing objects like ServiceAccount.
+	Kind string `json:"kind" yaml:"kind"`
+	// Name is the regular expression to filter the referencing objects Its name. By default, It's any name.
+	Name *string `json:"name,omitempty" yaml:"name"`
+	// FieldPaths are the field paths like secrets[*].name or imagePullSecrets[*].name that the name of the object
+	// should be one of their values.
+	// +kubebuilder:validation:MinItems=1
+	FieldPaths []string `json:"fieldPaths" yaml:"fieldPaths"`
+	Compiled   struct {
+		Name       *regexp.Regexp
+		FieldPaths [][]string
 	} `json:"-"`
 }
 
@@ -122,7 +372,14 @@ type CustomObjectFilter struct {
 	} `json:"-"`
 }
 
+type DestinationType string
+
+// +kubebuilder:validation:XValidation:rule="!has(self.type) || self.type != 'file' || has(self.file)"
+// +kubebuilder:validation:XValidation:rule="!has(self.type) || self.type != 'elasticsearch' || has(self.elasticsearch)"
 type Destination struct {
+	// Type is the type of the destination, It can be http, file, stdout or elasticsearch. By default, It's http.
+	// +kubebuilder:validation:Enum=http;file;stdout;elasticsearch
+	Type DestinationType `json:"type,omitempty" yaml:"type"`
 	// URLTemplate is the template field to set where will be the destination.
 	URLTemplate string `json:"urlTemplate,omitempty" yaml:"urlTemplate"`
 	// BodyTemplate is the template field to set what will be sent the destination.
@@ -131,6 +388,26 @@ type Destination struct {
 	HeaderTemplate string `json:"headerTemplate,omitempty" yaml:"headerTemplate"`
 	// Method is the HTTP method will be used while calling the destination endpoints.
 	Method string `json:"method,omitempty" yaml:"method"`
+	// File allows you to set file destination specific options, It's required by file destinations.
+	File *FileDestination `json:"file,omitempty" yaml:"file"`
+	// Elasticsearch allows you to set elasticsearch destination specific options, It's required by elasticsearch
+	// destinations.
+	// URLTemplate is used as the base address of the cluster and HeaderTemplate for its headers.
+	Elasticsearch *ElasticsearchDestination `json:"elasticsearch,omitempty" yaml:"elasticsearch"`
+	// Batch allows you to aggregate the rendered objects and send them together in a single request.
+	// It's supported by http and elasticsearch destinations, and the objects rendered to different URLs or headers are
+	// sent separately. Since each worker waits until its batch is sent, the concurrency of the sources is the maximum
+	// items of the batch by default.
+	Batch *BatchOptions `json:"batch,omitempty" yaml:"batch"`
+	// RateLimit allows you to limit the requests that will be sent to the destination.
+	RateLimit *RateLimitOptions `json:"rateLimit,omitempty" yaml:"rateLimit"`
+	// CircuitBreaker allows you to stop calling the destination for a while when it keeps failing.
+	// While it's open, the objects are requeued instead of being sent.
+	CircuitBreaker *CircuitBreakerOptions `json:"circuitBreaker,omitempty" yaml:"circuitBreaker"`
+	// Diff allows you to send what is changed instead of the whole object. The patch between the previous and
+	// the current object is available in the body template as .Patch, and objects without any change are not sent,
+	// like the objects of the events other than update. By default, The body template is {{ .Patch }}.
+	Diff *DiffOptions `json:"diff,omitempty" yaml:"diff"`
 	// Compiled is the compiled templates.
 	Compiled struct {
 		URLTemplate    *template.Template
@@ -139,9 +416,115 @@ type Destination struct {
 	} `json:"-"`
 }
 
+type ElasticsearchDestination struct {
+	// IndexTemplate is the template field to set which index the documents will be written to.
+	IndexTemplate string `json:"indexTemplate,omitempty" yaml:"indexTemplate"`
+	// IDTemplate is the template field to set the document id. By default, It's the uid of the object.
+	IDTemplate string `json:"idTemplate,omitempty" yaml:"idTemplate"`
+	// Finalize adds a finalizer of the watcher to the indexed objects, so their documents are deleted before they're
+	// removed even when the manager isn't running. The finalizers are left on the objects when the watcher is deleted.
+	Finalize bool `json:"finalize,omitempty" yaml:"finalize"`
+	Compiled struct {
+		IndexTemplate *template.Template
+		IDTemplate    *template.Template
+	} `json:"-"`
+}
+
+type BatchOptions struct {
+	// MaxItems is the maximum number of items that a batch can have. By default, It's 100.
+	MaxItems *int `json:"maxItems,omitempty" yaml:"maxItems"`
+	// MaxBytes is the size like 1Mi that the batch will be sent once the rendered items reach it.
+	// By default, It's not set.
+	// +kubebuilder:validation:XValidation:rule="isQuantity(self)"
+	MaxBytes *string `json:"maxBytes,omitempty" yaml:"maxBytes"`
+	// MaxWait is the maximum duration that an item can wait in the batch before it's sent. By default, It's 1s.
+	// +kubebuilder:validation:Format=duration
+	MaxWait *string `json:"maxWait,omitempty" yaml:"maxWait"`
+	// Template is the template field to wrap the rendered items, which are available as .items, into a single body.
+	// By default, It's a JSON array of the items. It's not used by elasticsearch destination.
+	Template string `json:"template,omitempty" yaml:"template"`
+	Compiled struct {
+		MaxBytes int64
+		MaxWait  time.Duration
+		Template *template.Template
+	} `json:"-"`
+}
+
+type RateLimitOptions struct {
+	// RequestsPerSecond is how many requests like 10 or 0.5 can be sent to the destination in a second.
+	// It's shared by all the workers, and it's slowed down adaptively when the destination responds with 429.
+	// +kubebuilder:validation:XValidation:rule="double(self) > 0.0",message="must be a positive number"
+	RequestsPerSecond string `json:"requestsPerSecond" yaml:"requestsPerSecond"`
+	// Burst is how many requests can be sent at once. By default, It's 1.
+	// +kubebuilder:validation:Minimum=1
+	Burst *int `json:"burst,omitempty" yaml:"burst"`
+	// Shared sets if the limit should be shared with the other watchers sending requests to the same host with the
+	// same requestsPerSecond and burst.
+	Shared   bool `json:"shared,omitempty" yaml:"shared"`
+	Compiled struct {
+		RequestsPerSecond float64
+	} `json:"-"`
+}
+
+type CircuitBreakerOptions struct {
+	// FailureThreshold is how many consecutive failures, which are the connection errors and the 5xx or 429
+	// responses, will open the circuit. By default, It's 5.
+	FailureThreshold *int `json:"failureThreshold,omitempty" yaml:"failureThreshold"`
+	// OpenDuration is the duration like 30s that the circuit stays open before probing the destination again.
+	// By default, It's 30s.
+	// +kubebuilder:validation:Format=duration
+	OpenDuration *string `json:"openDuration,omitempty" yaml:"openDuration"`
+	// HalfOpenProbes is how many successful probes will close the circuit again. By default, It's 1.
+	HalfOpenProbes *int `json:"halfOpenProbes,omitempty" yaml:"halfOpenProbes"`
+	Compiled       struct {
+		OpenDuration time.Duration
+	} `json:"-"`
+}
+
+type DiffFormat string
+
+type DiffOptions struct {
+	// Format is the format of the patch, It can be jsonPatch (RFC 6902) or mergePatch (RFC 7386).
+	// By default, It's jsonPatch.
+	// +kubebuilder:validation:Enum=jsonPatch;mergePatch
+	Format DiffFormat `json:"format,omitempty" yaml:"format"`
+	// IgnorePaths are the field paths like status.conditions[*].lastHeartbeatTime that will be left out of the patch.
+	// By default, It's metadata.managedFields and metadata.resourceVersion.
+	IgnorePaths []string `json:"ignorePaths,omitempty" yaml:"ignorePaths"`
+	Compiled    struct {
+		IgnorePaths [][]string
+	} `json:"-"`
+}
+
+type FileDestination struct {
+	// Path is the path of the file that the rendered objects will be appended to as lines. The watchers writing to the
+	// same path share the file, which is rotated with the options of the watcher set up last.
+	Path string `json:"path,omitempty" yaml:"path"`
+	// MaxSize is the size like 100Mi that the file will be rotated after exceeding it.
+	// By default, It's not set and the file is never rotated.
+	// +kubebuilder:validation:XValidation:rule="isQuantity(self)"
+	MaxSize *string `json:"maxSize,omitempty" yaml:"maxSize"`
+	// MaxBackups is how many rotated files will be kept. By default, It's 5.
+	MaxBackups *int `json:"maxBackups,omitempty" yaml:"maxBackups"`
+	// Compress sets if the rotated files should be compressed with gzip.
+	Compress bool `json:"compress,omitempty" yaml:"compress"`
+	Compiled struct {
+		MaxSize int64
+	} `json:"-"`
+}
+
 type ValuesFrom struct {
 	// Secrets are the references that will be merged from.
 	Secrets []SecretKeySelector `json:"secrets,omitempty"`
+	// ConfigMaps are the config maps whose data are available in templates as .Values, the latter ones overriding
+	// the former ones. They're watched, so the templates are rendered with their latest data once they're changed,
+	// and the missing ones are left out until they're created.
+	ConfigMaps []ConfigMapSelector `json:"configMaps,omitempty" yaml:"configMaps"`
+}
+
+type ConfigMapSelector struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
 }
 
 type SecretKeySelector struct {
@@ -150,7 +533,36 @@ type SecretKeySelector struct {
 	Key       string `json:"key"`
 }
 
-func (s *Source) NewObject() *unstructured.Unstructured {
+func (s *SecretKeySelector) String() string {
+	return s.Namespace + "/" + s.Name + "/" + s.Key
+}
+
+func (s *Source) NewObject() client.Object {
+	if s.MetadataOnly {
+		return &metav1.PartialObjectMetadata{
+			TypeMeta: metav1.TypeMeta{APIVersion: s.APIVersion, Kind: s.Kind},
+		}
+	}
+
+	return s.NewUnstructured()
+}
+
+// NewObjectList returns the list of the objects returned by NewObject.
+func (s *Source) NewObjectList() client.ObjectList {
+	if s.MetadataOnly {
+		return &metav1.PartialObjectMetadataList{
+			TypeMeta: metav1.TypeMeta{APIVersion: s.APIVersion, Kind: s.Kind + "List"},
+		}
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(s.APIVersion)
+	list.SetKind(s.Kind + "List")
+
+	return list
+}
+
+func (s *Source) NewUnstructured() *unstructured.Unstructured {
 	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": s.APIVersion,
@@ -159,47 +571,453 @@ func (s *Source) NewObject() *unstructured.Unstructured {
 	}
 }
 
-func (w *WatcherSpec) GetConcurrency() int {
-	if w.Source.Concurrency != nil {
-		return *w.Source.Concurrency
+func (s *Source) GroupVersionKind() schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(s.APIVersion, s.Kind)
+}
+
+func (s *Source) GetFieldSelector() fields.Selector {
+	if s.Compiled.FieldSelector != nil {
+		return s.Compiled.FieldSelector
+	}
+
+	return fields.Everything()
+}
+
+func (o *ObjectFilter) HasNamespaceSelector() bool {
+	if o.NamespaceSelector != nil || (o.Not != nil && o.Not.HasNamespaceSelector()) {
+		return true
+	}
+
+	for _, filter := range append(append([]ObjectFilter{}, o.AllOf...), o.AnyOf...) {
+		if filter.HasNamespaceSelector() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *ObjectFilter) GetLabelSelector() labels.Selector {
+	selector := labels.Everything()
+	if o.Labels != nil {
+		selector = labels.SelectorFromSet(*o.Labels)
+	}
+
+	if o.Compiled.LabelSelector != nil {
+		requirements, _ := o.Compiled.LabelSelector.Requirements()
+		selector = selector.Add(requirements...)
+	}
+
+	return selector
+}
+
+func (o *OwnerFilter) Matches(reference metav1.OwnerReference) bool {
+	if o.Controller && (reference.Controller == nil || !*reference.Controller) {
+		return false
+	}
+
+	if o.Kind != "" && (o.Compiled.Kind.Kind != reference.Kind || (o.Compiled.Kind.Group != "" &&
+		o.Compiled.Kind.Group != schema.FromAPIVersionAndKind(reference.APIVersion, reference.Kind).Group)) {
+		return false
+	}
+
+	return o.Name == nil || o.Compiled.Name.MatchString(reference.Name)
+}
+
+// Matches returns whether the referencing object is one of the filter, and references the name in the field paths.
+func (r *ReferenceFilter) Matches(obj *unstructured.Unstructured, name string) bool {
+	if r.Name != nil && !r.Compiled.Name.MatchString(obj.GetName()) {
+		return false
+	}
+
+	for _, segments := range r.Compiled.FieldPaths {
+		if slices.Contains(common.FieldPathGet(obj.Object, segments), any(name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *ConditionTransitionFilter) Matches(from, to, reason string) bool {
+	return (c.From == nil || *c.From == from) && (c.To == nil || *c.To == to) &&
+		(c.Reason == nil || c.Compiled.Reason.MatchString(reason))
+}
+
+func (d *Destination) GetDefaultBodyTemplate() string {
+	if d.Diff != nil {
+		return DefaultDiffBodyTemplate
+	}
+
+	if d.IsDocumentBased() {
+		return DefaultBodyTemplate
+	}
+
+	return ""
+}
+
+func (d *DiffOptions) GetIgnorePaths() []string {
+	if d.IgnorePaths != nil {
+		return d.IgnorePaths
+	}
+
+	return []string{"metadata.managedFields", "metadata.resourceVersion"}
+}
+
+func (s *Source) GetConcurrency() int {
+	if s.Concurrency != nil {
+		return *s.Concurrency
 	}
 
 	return 1
 }
 
+func (c *ClusterSource) GetName() string {
+	if c.Name != nil {
+		return *c.Name
+	}
+
+	return c.KubeConfig.Name
+}
+
+func (s *Source) IsWildcard() bool {
+	return s.Kind == SourceKindAll
+}
+
+func (w *WatcherSpec) GetSources() []Source {
+	if w.Source.Kind == "" && len(w.Sources) > 0 {
+		return w.Sources
+	}
+
+	return append([]Source{w.Source}, w.Sources...)
+}
+
+func (f *FileDestination) GetMaxBackups() int {
+	if f.MaxBackups != nil {
+		return *f.MaxBackups
+	}
+
+	return DefaultFileMaxBackups
+}
+
+func (b *BatchOptions) GetMaxItems() int {
+	if b.MaxItems != nil {
+		return *b.MaxItems
+	}
+
+	return DefaultBatchMaxItems
+}
+
+func (r *RateLimitOptions) GetBurst() int {
+	if r.Burst != nil {
+		return *r.Burst
+	}
+
+	return 1
+}
+
+func (c *CircuitBreakerOptions) GetFailureThreshold() int {
+	if c.FailureThreshold != nil {
+		return *c.FailureThreshold
+	}
+
+	return DefaultCircuitBreakerFailureThreshold
+}
+
+func (c *CircuitBreakerOptions) GetHalfOpenProbes() int {
+	if c.HalfOpenProbes != nil {
+		return *c.HalfOpenProbes
+	}
+
+	return 1
+}
+
+func (d *Destination) IsLineBased() bool {
+	return d.Type == DestinationTypeFile || d.Type == DestinationTypeStdout
+}
+
+func (d *Destination) IsDocumentBased() bool {
+	return d.IsLineBased() || d.Type == DestinationTypeElasticsearch
+}
+
+// AsWatcher returns the watcher in its namespace, which is how namespaced watchers are run.
+func (w *NamespacedWatcher) AsWatcher() Watcher {
+	return Watcher{
+		TypeMeta:   metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: KindWatcher},
+		ObjectMeta: *w.ObjectMeta.DeepCopy(),
+		Spec:       *w.Spec.DeepCopy(),
+		Status:     *w.Status.DeepCopy(),
+	}
+}
+
+// IsNamespaced is whether the watcher is a NamespacedWatcher, which is restricted to its own namespace.
+func (w *Watcher) IsNamespaced() bool {
+	return w.GetNamespace() != ""
+}
+
+// Key is the name of the watcher, prefixed with its namespace when it's namespaced.
+func (w *Watcher) Key() string {
+	if w.IsNamespaced() {
+		return w.GetNamespace() + "/" + w.GetName()
+	}
+
+	return w.GetName()
+}
+
+// ServiceAccount returns the namespace and the name of the service account of the watcher, which is in the
+// namespace of the watcher unless it's qualified.
+func (w *Watcher) ServiceAccount() (string, string) {
+	if w.Spec.ServiceAccountName == nil {
+		return "", ""
+	}
+
+	if namespace, name, found := strings.Cut(*w.Spec.ServiceAccountName, "/"); found {
+		return namespace, name
+	}
+
+	return w.GetNamespace(), *w.Spec.ServiceAccountName
+}
+
+// StatusKind is the kind that the status of the watcher is updated in.
+func (w *Watcher) StatusKind() string {
+	if w.IsNamespaced() {
+		return KindNamespacedWatcher
+	}
+
+	return KindWatcher
+}
+
 func (w *Watcher) Compile() *Watcher {
 	newWatcher := w.DeepCopy()
 
-	if newWatcher.Spec.Filter.Object.Custom != nil {
-		newWatcher.Spec.Filter.Object.Custom.Compiled.Template = common.
-			TemplateParse(newWatcher.Spec.Filter.Object.Custom.Template)
+	newWatcher.Spec.Compiled.Sources = newWatcher.Spec.GetSources()
+	for index := range newWatcher.Spec.Compiled.Sources {
+		newWatcher.Spec.Compiled.Sources[index].Compile()
 	}
 
-	if newWatcher.Spec.Filter.Object.Name != nil {
-		newWatcher.Spec.Filter.Object.Compiled.Name = regexp.
-			MustCompile(*newWatcher.Spec.Filter.Object.Name)
+	newWatcher.Spec.Filter.Object.Compile()
+	newWatcher.Spec.Filter.Event.Compile()
+	newWatcher.Spec.Destination.Compile()
+
+	return newWatcher
+}
+
+func (s *Source) Compile() {
+	if s.FieldSelector != nil {
+		s.Compiled.FieldSelector = common.MustReturn(fields.ParseSelector(*s.FieldSelector))
 	}
 
-	if newWatcher.Spec.Filter.Object.Namespace != nil {
-		newWatcher.Spec.Filter.Object.Compiled.Namespace = regexp.
-			MustCompile(*newWatcher.Spec.Filter.Object.Namespace)
+	if s.Events != nil {
+		s.Events.Compile()
 	}
+}
 
-	if newWatcher.Spec.Filter.Event.Create.CreationTimeout != nil {
-		newWatcher.Spec.Filter.Event.Create.Compiled.CreationTimeout = common.
-			MustReturn(time.ParseDuration(*newWatcher.Spec.Filter.Event.Create.CreationTimeout))
+func (e *EventsSource) Compile() {
+	if e.AggregationWindow != nil {
+		e.Compiled.AggregationWindow = common.MustReturn(time.ParseDuration(*e.AggregationWindow))
 	}
+}
 
-	newWatcher.Spec.Destination.Compiled.URLTemplate = common.
-		TemplateParse(newWatcher.Spec.Destination.URLTemplate)
-	newWatcher.Spec.Destination.Compiled.BodyTemplate = common.
-		TemplateParse(newWatcher.Spec.Destination.BodyTemplate)
-	newWatcher.Spec.Destination.Compiled.HeaderTemplate = common.
-		TemplateParse(newWatcher.Spec.Destination.HeaderTemplate)
+func (o *ObjectFilter) Compile() {
+	if o.Custom != nil {
+		o.Custom.Compiled.Template = common.TemplateParse(o.Custom.Template)
+	}
 
-	return newWatcher
+	if o.Name != nil {
+		o.Compiled.Name = regexp.MustCompile(*o.Name)
+	}
+
+	if o.Namespace != nil {
+		o.Compiled.Namespace = regexp.MustCompile(*o.Namespace)
+	}
+
+	o.compileRelations()
+
+	if o.CEL != nil {
+		o.Compiled.CEL = common.CELParse(*o.CEL)
+	}
+
+	o.compileSelectors()
+
+	for index := range o.AllOf {
+		o.AllOf[index].Compile()
+	}
+
+	for index := range o.AnyOf {
+		o.AnyOf[index].Compile()
+	}
+
+	if o.Not != nil {
+		o.Not.Compile()
+	}
+}
+
+func (o *ObjectFilter) compileRelations() {
+	if o.Owner != nil {
+		o.Owner.Compiled.Kind = schema.ParseGroupKind(o.Owner.Kind)
+	}
+
+	if o.Owner != nil && o.Owner.Name != nil {
+		o.Owner.Compiled.Name = regexp.MustCompile(*o.Owner.Name)
+	}
+
+	if o.ReferencedBy == nil {
+		return
+	}
+
+	if o.ReferencedBy.Name != nil {
+		o.ReferencedBy.Compiled.Name = regexp.MustCompile(*o.ReferencedBy.Name)
+	}
+
+	o.ReferencedBy.Compiled.FieldPaths = make([][]string, 0, len(o.ReferencedBy.FieldPaths))
+	for _, path := range o.ReferencedBy.FieldPaths {
+		o.ReferencedBy.Compiled.FieldPaths = append(o.ReferencedBy.Compiled.FieldPaths, common.FieldPathParse(path))
+	}
+}
+
+func (o *ObjectFilter) compileSelectors() {
+	if o.LabelSelector != nil {
+		o.Compiled.LabelSelector = common.MustReturn(metav1.LabelSelectorAsSelector(o.LabelSelector))
+	}
+
+	if o.AnnotationSelector != nil {
+		o.Compiled.AnnotationSelector = common.MustReturn(metav1.LabelSelectorAsSelector(o.AnnotationSelector))
+	}
+
+	if o.NamespaceSelector != nil {
+		o.Compiled.NamespaceSelector = common.MustReturn(metav1.LabelSelectorAsSelector(o.NamespaceSelector))
+	}
+}
+
+func (e *EventFilter) Compile() {
+	if e.Debounce != nil {
+		e.Compiled.Debounce = common.MustReturn(time.ParseDuration(*e.Debounce))
+		e.Compiled.DebounceMaxWait = DefaultDebounceMaxWaitFactor * e.Compiled.Debounce
+	}
+
+	if e.DebounceMaxWait != nil {
+		e.Compiled.DebounceMaxWait = common.MustReturn(time.ParseDuration(*e.DebounceMaxWait))
+	}
+
+	if e.Create.CreationTimeout != nil {
+		e.Create.Compiled.CreationTimeout = common.MustReturn(time.ParseDuration(*e.Create.CreationTimeout))
+	}
+
+	if e.ConditionTransition != nil && e.ConditionTransition.Reason != nil {
+		e.ConditionTransition.Compiled.Reason = regexp.MustCompile(*e.ConditionTransition.Reason)
+	}
+
+	for _, path := range e.Update.FieldsChanged {
+		e.Update.Compiled.FieldsChanged = append(e.Update.Compiled.FieldsChanged, common.FieldPathParse(path))
+	}
+
+	for _, path := range e.Update.IgnoreFields {
+		e.Update.Compiled.IgnoreFields = append(e.Update.Compiled.IgnoreFields, common.FieldPathParse(path))
+	}
+}
+
+func (d *Destination) Compile() {
+	common.Must(d.Validate())
+
+	if d.File != nil && d.File.MaxSize != nil {
+		maxSize := resource.MustParse(*d.File.MaxSize)
+		d.File.Compiled.MaxSize = maxSize.Value()
+	}
+
+	if d.BodyTemplate == "" {
+		d.BodyTemplate = d.GetDefaultBodyTemplate()
+	}
+
+	if d.Diff != nil {
+		d.Diff.Compile()
+	}
+
+	if d.Elasticsearch != nil {
+		if d.Elasticsearch.IDTemplate == "" {
+			d.Elasticsearch.IDTemplate = DefaultElasticsearchIDTemplate
+		}
+
+		d.Elasticsearch.Compiled.IndexTemplate = common.TemplateParse(d.Elasticsearch.IndexTemplate)
+		d.Elasticsearch.Compiled.IDTemplate = common.TemplateParse(d.Elasticsearch.IDTemplate)
+	}
+
+	if d.Batch != nil {
+		d.Batch.Compile()
+	}
+
+	if d.CircuitBreaker != nil {
+		d.CircuitBreaker.Compiled.OpenDuration = DefaultCircuitBreakerOpenDuration
+		if d.CircuitBreaker.OpenDuration != nil {
+			d.CircuitBreaker.Compiled.OpenDuration = common.MustReturn(time.ParseDuration(*d.CircuitBreaker.OpenDuration))
+		}
+	}
+
+	if d.RateLimit != nil {
+		d.RateLimit.Compiled.RequestsPerSecond = common.MustReturn(strconv.ParseFloat(d.RateLimit.RequestsPerSecond, 64))
+	}
+
+	d.Compiled.URLTemplate = common.TemplateParse(d.URLTemplate)
+	d.Compiled.BodyTemplate = common.TemplateParse(d.BodyTemplate)
+	d.Compiled.HeaderTemplate = common.TemplateParse(d.HeaderTemplate)
+}
+
+// Validate returns an error when the options of the type of the destination are missing.
+func (d *Destination) Validate() error {
+	if d.Type == DestinationTypeFile && d.File == nil {
+		return fmt.Errorf("%w: %s destinations need the file options", ErrDestinationOptionsMissing, d.Type)
+	}
+
+	if d.Type == DestinationTypeElasticsearch && d.Elasticsearch == nil {
+		return fmt.Errorf("%w: %s destinations need the elasticsearch options", ErrDestinationOptionsMissing, d.Type)
+	}
+
+	if d.RateLimit != nil {
+		return d.RateLimit.Validate()
+	}
+
+	return nil
+}
+
+// Validate returns an error unless the requests per second is a positive number and the burst is at least 1, since
+// the requests would never be sent otherwise.
+func (r *RateLimitOptions) Validate() error {
+	requestsPerSecond, parseErr := strconv.ParseFloat(r.RequestsPerSecond, 64)
+	if parseErr != nil {
+		return fmt.Errorf("%w: %w", ErrRateLimitInvalid, parseErr)
+	}
+
+	if requestsPerSecond <= 0 || r.GetBurst() < 1 {
+		return fmt.Errorf("%w: requestsPerSecond %s and burst %d must be positive", ErrRateLimitInvalid,
+			r.RequestsPerSecond, r.GetBurst())
+	}
+
+	return nil
+}
+
+func (d *DiffOptions) Compile() {
+	for _, path := range d.GetIgnorePaths() {
+		d.Compiled.IgnorePaths = append(d.Compiled.IgnorePaths, common.FieldPathParse(path))
+	}
+}
+
+func (b *BatchOptions) Compile() {
+	b.Compiled.MaxWait = DefaultBatchMaxWait
+	if b.MaxWait != nil {
+		b.Compiled.MaxWait = common.MustReturn(time.ParseDuration(*b.MaxWait))
+	}
+
+	if b.MaxBytes != nil {
+		maxBytes := resource.MustParse(*b.MaxBytes)
+		b.Compiled.MaxBytes = maxBytes.Value()
+	}
+
+	if b.Template == "" {
+		b.Template = DefaultBatchTemplate
+	}
+
+	b.Compiled.Template = common.TemplateParse(b.Template)
 }
 
 func init() {
-	SchemeBuilder.Register(&Watcher{}, &WatcherList{})
+	SchemeBuilder.Register(&Watcher{}, &WatcherList{}, &NamespacedWatcher{}, &NamespacedWatcherList{})
 }
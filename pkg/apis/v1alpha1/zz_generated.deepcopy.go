@@ -5,9 +5,136 @@
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BatchOptions) DeepCopyInto(out *BatchOptions) {
+	*out = *in
+	if in.MaxItems != nil {
+		in, out := &in.MaxItems, &out.MaxItems
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxBytes != nil {
+		in, out := &in.MaxBytes, &out.MaxBytes
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxWait != nil {
+		in, out := &in.MaxWait, &out.MaxWait
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BatchOptions.
+func (in *BatchOptions) DeepCopy() *BatchOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(BatchOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CircuitBreakerOptions) DeepCopyInto(out *CircuitBreakerOptions) {
+	*out = *in
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int)
+		**out = **in
+	}
+	if in.OpenDuration != nil {
+		in, out := &in.OpenDuration, &out.OpenDuration
+		*out = new(string)
+		**out = **in
+	}
+	if in.HalfOpenProbes != nil {
+		in, out := &in.HalfOpenProbes, &out.HalfOpenProbes
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CircuitBreakerOptions.
+func (in *CircuitBreakerOptions) DeepCopy() *CircuitBreakerOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(CircuitBreakerOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSource) DeepCopyInto(out *ClusterSource) {
+	*out = *in
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	out.KubeConfig = in.KubeConfig
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSource.
+func (in *ClusterSource) DeepCopy() *ClusterSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConditionTransitionFilter) DeepCopyInto(out *ConditionTransitionFilter) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = new(string)
+		**out = **in
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = new(string)
+		**out = **in
+	}
+	if in.Reason != nil {
+		in, out := &in.Reason, &out.Reason
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConditionTransitionFilter.
+func (in *ConditionTransitionFilter) DeepCopy() *ConditionTransitionFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(ConditionTransitionFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapSelector) DeepCopyInto(out *ConfigMapSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapSelector.
+func (in *ConfigMapSelector) DeepCopy() *ConfigMapSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CreateEventFilter) DeepCopyInto(out *CreateEventFilter) {
 	*out = *in
@@ -46,6 +173,36 @@ func (in *CustomObjectFilter) DeepCopy() *CustomObjectFilter {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Destination) DeepCopyInto(out *Destination) {
 	*out = *in
+	if in.File != nil {
+		in, out := &in.File, &out.File
+		*out = new(FileDestination)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Elasticsearch != nil {
+		in, out := &in.Elasticsearch, &out.Elasticsearch
+		*out = new(ElasticsearchDestination)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Batch != nil {
+		in, out := &in.Batch, &out.Batch
+		*out = new(BatchOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(RateLimitOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(CircuitBreakerOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Diff != nil {
+		in, out := &in.Diff, &out.Diff
+		*out = new(DiffOptions)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Destination.
@@ -58,11 +215,61 @@ func (in *Destination) DeepCopy() *Destination {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiffOptions) DeepCopyInto(out *DiffOptions) {
+	*out = *in
+	if in.IgnorePaths != nil {
+		in, out := &in.IgnorePaths, &out.IgnorePaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiffOptions.
+func (in *DiffOptions) DeepCopy() *DiffOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(DiffOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDestination) DeepCopyInto(out *ElasticsearchDestination) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDestination.
+func (in *ElasticsearchDestination) DeepCopy() *ElasticsearchDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EventFilter) DeepCopyInto(out *EventFilter) {
 	*out = *in
 	in.Create.DeepCopyInto(&out.Create)
 	in.Update.DeepCopyInto(&out.Update)
+	if in.Debounce != nil {
+		in, out := &in.Debounce, &out.Debounce
+		*out = new(string)
+		**out = **in
+	}
+	if in.DebounceMaxWait != nil {
+		in, out := &in.DebounceMaxWait, &out.DebounceMaxWait
+		*out = new(string)
+		**out = **in
+	}
+	if in.ConditionTransition != nil {
+		in, out := &in.ConditionTransition, &out.ConditionTransition
+		*out = new(ConditionTransitionFilter)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventFilter.
@@ -75,6 +282,51 @@ func (in *EventFilter) DeepCopy() *EventFilter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventsSource) DeepCopyInto(out *EventsSource) {
+	*out = *in
+	if in.AggregationWindow != nil {
+		in, out := &in.AggregationWindow, &out.AggregationWindow
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventsSource.
+func (in *EventsSource) DeepCopy() *EventsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(EventsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileDestination) DeepCopyInto(out *FileDestination) {
+	*out = *in
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxBackups != nil {
+		in, out := &in.MaxBackups, &out.MaxBackups
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileDestination.
+func (in *FileDestination) DeepCopy() *FileDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(FileDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Filter) DeepCopyInto(out *Filter) {
 	*out = *in
@@ -92,6 +344,65 @@ func (in *Filter) DeepCopy() *Filter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedWatcher) DeepCopyInto(out *NamespacedWatcher) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedWatcher.
+func (in *NamespacedWatcher) DeepCopy() *NamespacedWatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedWatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NamespacedWatcher) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedWatcherList) DeepCopyInto(out *NamespacedWatcherList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NamespacedWatcher, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedWatcherList.
+func (in *NamespacedWatcherList) DeepCopy() *NamespacedWatcherList {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedWatcherList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NamespacedWatcherList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectFilter) DeepCopyInto(out *ObjectFilter) {
 	*out = *in
@@ -127,11 +438,60 @@ func (in *ObjectFilter) DeepCopyInto(out *ObjectFilter) {
 			}
 		}
 	}
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AnnotationSelector != nil {
+		in, out := &in.AnnotationSelector, &out.AnnotationSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Owner != nil {
+		in, out := &in.Owner, &out.Owner
+		*out = new(OwnerFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReferencedBy != nil {
+		in, out := &in.ReferencedBy, &out.ReferencedBy
+		*out = new(ReferenceFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CEL != nil {
+		in, out := &in.CEL, &out.CEL
+		*out = new(string)
+		**out = **in
+	}
 	if in.Custom != nil {
 		in, out := &in.Custom, &out.Custom
 		*out = new(CustomObjectFilter)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AllOf != nil {
+		in, out := &in.AllOf, &out.AllOf
+		*out = make([]ObjectFilter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AnyOf != nil {
+		in, out := &in.AnyOf, &out.AnyOf
+		*out = make([]ObjectFilter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Not != nil {
+		in, out := &in.Not, &out.Not
+		*out = new(ObjectFilter)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectFilter.
@@ -159,6 +519,71 @@ func (in *OnSuccessSourceOptions) DeepCopy() *OnSuccessSourceOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnerFilter) DeepCopyInto(out *OwnerFilter) {
+	*out = *in
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnerFilter.
+func (in *OwnerFilter) DeepCopy() *OwnerFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnerFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitOptions) DeepCopyInto(out *RateLimitOptions) {
+	*out = *in
+	if in.Burst != nil {
+		in, out := &in.Burst, &out.Burst
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitOptions.
+func (in *RateLimitOptions) DeepCopy() *RateLimitOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReferenceFilter) DeepCopyInto(out *ReferenceFilter) {
+	*out = *in
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.FieldPaths != nil {
+		in, out := &in.FieldPaths, &out.FieldPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReferenceFilter.
+func (in *ReferenceFilter) DeepCopy() *ReferenceFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(ReferenceFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretKeySelector) DeepCopyInto(out *SecretKeySelector) {
 	*out = *in
@@ -177,11 +602,31 @@ func (in *SecretKeySelector) DeepCopy() *SecretKeySelector {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Source) DeepCopyInto(out *Source) {
 	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FieldSelector != nil {
+		in, out := &in.FieldSelector, &out.FieldSelector
+		*out = new(string)
+		**out = **in
+	}
 	if in.Concurrency != nil {
 		in, out := &in.Concurrency, &out.Concurrency
 		*out = new(int)
 		**out = **in
 	}
+	if in.Cluster != nil {
+		in, out := &in.Cluster, &out.Cluster
+		*out = new(ClusterSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = new(EventsSource)
+		(*in).DeepCopyInto(*out)
+	}
 	out.Options = in.Options
 }
 
@@ -224,6 +669,16 @@ func (in *UpdateEventFilter) DeepCopyInto(out *UpdateEventFilter) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.FieldsChanged != nil {
+		in, out := &in.FieldsChanged, &out.FieldsChanged
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IgnoreFields != nil {
+		in, out := &in.IgnoreFields, &out.IgnoreFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateEventFilter.
@@ -244,6 +699,11 @@ func (in *ValuesFrom) DeepCopyInto(out *ValuesFrom) {
 		*out = make([]SecretKeySelector, len(*in))
 		copy(*out, *in)
 	}
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]ConfigMapSelector, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesFrom.
@@ -262,6 +722,7 @@ func (in *Watcher) DeepCopyInto(out *Watcher) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Watcher.
@@ -318,9 +779,28 @@ func (in *WatcherList) DeepCopyObject() runtime.Object {
 func (in *WatcherSpec) DeepCopyInto(out *WatcherSpec) {
 	*out = *in
 	in.Source.DeepCopyInto(&out.Source)
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]Source, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Filter.DeepCopyInto(&out.Filter)
 	in.Destination.DeepCopyInto(&out.Destination)
 	in.ValuesFrom.DeepCopyInto(&out.ValuesFrom)
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ServiceAccountName != nil {
+		in, out := &in.ServiceAccountName, &out.ServiceAccountName
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WatcherSpec.
@@ -332,3 +812,25 @@ func (in *WatcherSpec) DeepCopy() *WatcherSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WatcherStatus) DeepCopyInto(out *WatcherStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WatcherStatus.
+func (in *WatcherStatus) DeepCopy() *WatcherStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WatcherStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,166 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type BatchItem struct {
+	Object    *unstructured.Unstructured
+	Body      []byte
+	Operation BulkOperation
+
+	result chan error
+}
+
+type BatchFlushFunc func(ctx context.Context, items []*BatchItem) []error
+
+type Batcher struct {
+	maxItems int
+	maxBytes int64
+	maxWait  time.Duration
+	flush    BatchFlushFunc
+
+	mutex   sync.Mutex
+	pending []*BatchItem
+	size    int64
+	timer   *time.Timer
+	// expired signals the batcher to flush the pending items once the timer fires, so they're sent within the context
+	// of the manager.
+	expired chan struct{}
+}
+
+func NewBatcher(maxItems int, maxBytes int64, maxWait time.Duration, flush BatchFlushFunc) *Batcher {
+	return &Batcher{
+		maxItems: maxItems,
+		maxBytes: maxBytes,
+		maxWait:  maxWait,
+		flush:    flush,
+		expired:  make(chan struct{}, 1),
+	}
+}
+
+// Start sends the pending items once the timer fires within the context, and fails them with its error on shutdown.
+func (b *Batcher) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-b.expired:
+			b.flushPending(ctx)
+		case <-ctx.Done():
+			b.mutex.Lock()
+			items := b.take()
+			b.mutex.Unlock()
+
+			for _, item := range items {
+				item.result <- ctx.Err()
+			}
+
+			return nil
+		}
+	}
+}
+
+func (b *Batcher) Add(ctx context.Context, item *BatchItem) error {
+	item.result = make(chan error, 1)
+
+	b.mutex.Lock()
+
+	b.pending = append(b.pending, item)
+	b.size += int64(len(item.Body) + len(item.Operation.Document))
+
+	var items []*BatchItem
+	if len(b.pending) >= b.maxItems || (b.maxBytes > 0 && b.size >= b.maxBytes) {
+		items = b.take()
+	} else if b.timer == nil {
+		b.timer = time.AfterFunc(b.maxWait, b.expire)
+	}
+
+	b.mutex.Unlock()
+
+	if len(items) > 0 {
+		b.send(context.WithoutCancel(ctx), items)
+	}
+
+	select {
+	case resultErr := <-item.result:
+		return resultErr
+	case <-ctx.Done():
+		b.remove(item)
+
+		return ctx.Err()
+	}
+}
+
+// remove removes the item from the pending items, unless it's already being sent.
+func (b *Batcher) remove(item *BatchItem) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for index, pending := range b.pending {
+		if pending != item {
+			continue
+		}
+
+		b.pending = append(b.pending[:index], b.pending[index+1:]...)
+		b.size -= int64(len(item.Body) + len(item.Operation.Document))
+
+		if len(b.pending) == 0 {
+			b.take()
+		}
+
+		return
+	}
+}
+
+func (b *Batcher) expire() {
+	select {
+	case b.expired <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Batcher) flushPending(ctx context.Context) {
+	b.mutex.Lock()
+	items := b.take()
+	b.mutex.Unlock()
+
+	if len(items) > 0 {
+		b.send(ctx, items)
+	}
+}
+
+func (b *Batcher) take() []*BatchItem {
+	items := b.pending
+	b.pending, b.size = nil, 0
+
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+
+	return items
+}
+
+func (b *Batcher) send(ctx context.Context, items []*BatchItem) {
+	results := b.flush(ctx, items)
+
+	for index, item := range items {
+		if index < len(results) {
+			item.result <- results[index]
+		} else {
+			item.result <- nil
+		}
+	}
+}
+
+func repeatError(err error, count int) []error {
+	errs := make([]error, count)
+	for index := range errs {
+		errs[index] = err
+	}
+
+	return errs
+}
@@ -0,0 +1,263 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestBatcher_AddMaxItems(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		flushes [][]string
+		mutex   sync.Mutex
+		group   sync.WaitGroup
+		batcher = NewBatcher(3, 0, time.Hour, func(ctx context.Context, items []*BatchItem) []error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			var bodies []string
+			for _, item := range items {
+				bodies = append(bodies, string(item.Body))
+			}
+			sort.Strings(bodies)
+			flushes = append(flushes, bodies)
+
+			return nil
+		})
+		results = make([]error, 3)
+	)
+
+	// when
+	for index, body := range []string{"a", "b", "c"} {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			results[index] = batcher.Add(ctx, &BatchItem{Body: []byte(body)})
+		}()
+	}
+	group.Wait()
+
+	// then
+	assert.Equal(t, [][]string{{"a", "b", "c"}}, flushes)
+	assert.Equal(t, []error{nil, nil, nil}, results)
+}
+
+func TestBatcher_AddMaxBytes(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		flushed int
+		batcher = NewBatcher(100, 4, time.Hour, func(ctx context.Context, items []*BatchItem) []error {
+			flushed = len(items)
+
+			return nil
+		})
+	)
+
+	// when
+	addErr := batcher.Add(ctx, &BatchItem{Body: []byte("large")})
+
+	// then
+	assert.Nil(t, addErr)
+	assert.Equal(t, 1, flushed)
+}
+
+func TestBatcher_AddMaxWait(t *testing.T) {
+	// given
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		start       = time.Now()
+		batcher     = NewBatcher(100, 0, 50*time.Millisecond, func(ctx context.Context, items []*BatchItem) []error {
+			return []error{errors.New("failed")}
+		})
+	)
+	defer cancel()
+
+	go func() {
+		_ = batcher.Start(ctx)
+	}()
+
+	// when
+	addErr := batcher.Add(ctx, &BatchItem{Body: []byte("a")})
+
+	// then
+	assert.EqualError(t, addErr, "failed")
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestBatcher_AddContextCancelled(t *testing.T) {
+	// given
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		batcher     = NewBatcher(100, 0, time.Hour, func(ctx context.Context, items []*BatchItem) []error {
+			return nil
+		})
+	)
+	cancel()
+
+	// when
+	addErr := batcher.Add(ctx, &BatchItem{Body: []byte("a")})
+
+	// then
+	assert.ErrorIs(t, addErr, context.Canceled)
+	assert.Empty(t, batcher.pending)
+	assert.Zero(t, batcher.size)
+	assert.Nil(t, batcher.timer)
+}
+
+func TestBatcher_Start(t *testing.T) {
+	// given
+	type key struct{}
+
+	var (
+		managerCtx, cancel = context.WithCancel(context.WithValue(context.Background(), key{}, "manager"))
+		flushedCtx         = make(chan context.Context, 1)
+		batcher            = NewBatcher(100, 0, 10*time.Millisecond, func(ctx context.Context, items []*BatchItem) []error {
+			flushedCtx <- ctx
+
+			return nil
+		})
+		stopped    = make(chan error)
+		pendingErr = make(chan error)
+	)
+
+	go func() {
+		stopped <- batcher.Start(managerCtx)
+	}()
+
+	// when
+	flushedErr := batcher.Add(context.Background(), &BatchItem{Body: []byte("a")})
+
+	batcher.mutex.Lock()
+	batcher.maxWait = time.Hour
+	batcher.mutex.Unlock()
+
+	go func() {
+		pendingErr <- batcher.Add(context.Background(), &BatchItem{Body: []byte("b")})
+	}()
+
+	assert.Eventually(t, func() bool {
+		batcher.mutex.Lock()
+		defer batcher.mutex.Unlock()
+
+		return len(batcher.pending) == 1
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	// then
+	assert.Nil(t, flushedErr)
+	assert.Equal(t, "manager", (<-flushedCtx).Value(key{}))
+	assert.ErrorIs(t, <-pendingErr, context.Canceled)
+	assert.Nil(t, <-stopped)
+	assert.Nil(t, batcher.timer)
+}
+
+func TestController_Reconcile_Batch(t *testing.T) {
+	// given
+	var (
+		ctx    = context.Background()
+		bodies []string
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+		}))
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Destination: v1alpha1.Destination{
+					URLTemplate:  server.URL,
+					BodyTemplate: "\"{{ .metadata.name }}\"",
+					Method:       "POST",
+					Batch: &v1alpha1.BatchOptions{
+						MaxItems: ptr.To(2),
+						MaxWait:  ptr.To("1m"),
+					},
+				},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		controller = NewController(mockClient, server.Client(), watcher)
+		group      sync.WaitGroup
+		results    = make([]error, 2)
+	)
+	defer server.Close()
+	mockClient.EXPECT().Get(mock.Anything, mock.Anything, mock.AnythingOfType("*unstructured.Unstructured")).
+		RunAndReturn(func(ctx context.Context, key types.NamespacedName, obj client.Object,
+			opts ...client.GetOption,
+		) error {
+			obj.(*unstructured.Unstructured).SetName(key.Name)
+			return nil
+		})
+
+	// when
+	for index, name := range []string{"first", "second"} {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			_, results[index] = controller.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+		}()
+	}
+	group.Wait()
+
+	// then
+	assert.Equal(t, []error{nil, nil}, results)
+	assert.Len(t, bodies, 1)
+	assert.Contains(t, []string{`["first","second"]`, `["second","first"]`}, bodies[0])
+}
+
+func TestController_SendItems_GroupsByURL(t *testing.T) {
+	// given
+	var (
+		ctx      = context.Background()
+		requests = map[string]string{}
+		server   = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			requests[r.URL.Path] = string(body)
+		}))
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Destination: v1alpha1.Destination{
+					URLTemplate: server.URL + "/{{ .metadata.namespace }}",
+					Method:      "POST",
+					Batch:       &v1alpha1.BatchOptions{MaxItems: ptr.To(3)},
+				},
+			},
+		}).Compile()
+		controller = NewController(new(client2.MockClient), server.Client(), watcher)
+		newItem    = func(namespace, name string) *BatchItem {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			obj.SetNamespace(namespace)
+			obj.SetName(name)
+
+			return &BatchItem{Object: obj, Body: []byte(`"` + name + `"`)}
+		}
+	)
+	defer server.Close()
+
+	// when
+	errs := controller.SendItems(ctx, []*BatchItem{
+		newItem("team-a", "first"), newItem("team-b", "second"), newItem("team-a", "third"),
+	})
+
+	// then
+	assert.Equal(t, []error{nil, nil, nil}, errs)
+	assert.Equal(t, map[string]string{"/team-a": `["first","third"]`, "/team-b": `["second"]`}, requests)
+	assert.Equal(t, 3, controller.concurrency())
+}
@@ -0,0 +1,202 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	CircuitStateClosed   CircuitState = "Closed"
+	CircuitStateOpen     CircuitState = "Open"
+	CircuitStateHalfOpen CircuitState = "HalfOpen"
+)
+
+var ErrCircuitOpen = errors.New("circuit is open")
+
+type CircuitState string
+
+// responseError is the error of a response of the destination with its status code.
+type responseError interface {
+	error
+	statusCode() int
+}
+
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s, retrying after %s", ErrCircuitOpen, e.RetryAfter)
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// CircuitPermit is the permission of a request to call the destination, which is a probe when it's let through
+// while the circuit is half-open.
+type CircuitPermit struct {
+	probe bool
+	// halfOpened is the number of the times that the circuit was half-open when the probe was let through.
+	halfOpened int
+}
+
+type CircuitBreaker struct {
+	failureThreshold int
+	openDuration     time.Duration
+	halfOpenProbes   int
+	onChange         func(state CircuitState)
+
+	mutex      sync.Mutex
+	state      CircuitState
+	failures   int
+	successes  int
+	probing    int
+	halfOpened int
+	openedAt   time.Time
+}
+
+func NewCircuitBreaker(failureThreshold int, openDuration time.Duration, halfOpenProbes int,
+	onChange func(state CircuitState),
+) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		halfOpenProbes:   halfOpenProbes,
+		onChange:         onChange,
+		state:            CircuitStateClosed,
+	}
+}
+
+// Allow returns the permit of a request to call the destination, which is recorded with its result.
+func (b *CircuitBreaker) Allow() (CircuitPermit, error) {
+	b.mutex.Lock()
+
+	previous := b.state
+
+	if b.state == CircuitStateOpen {
+		if remaining := b.openDuration - time.Since(b.openedAt); remaining > 0 {
+			b.mutex.Unlock()
+
+			return CircuitPermit{}, &CircuitOpenError{RetryAfter: remaining}
+		}
+
+		b.state, b.successes, b.probing = CircuitStateHalfOpen, 0, 0
+		b.halfOpened++
+	}
+
+	var permit CircuitPermit
+
+	if b.state == CircuitStateHalfOpen {
+		if b.probing >= b.halfOpenProbes {
+			b.mutex.Unlock()
+
+			return CircuitPermit{}, &CircuitOpenError{RetryAfter: b.openDuration}
+		}
+
+		b.probing++
+		permit = CircuitPermit{probe: true, halfOpened: b.halfOpened}
+	}
+
+	current := b.state
+	b.mutex.Unlock()
+	b.notify(previous, current)
+
+	return permit, nil
+}
+
+// Record records the result of the request, where only the probes of the current half-open circuit can close or
+// open it again.
+func (b *CircuitBreaker) Record(permit CircuitPermit, err error) {
+	b.mutex.Lock()
+
+	previous := b.state
+
+	switch b.state {
+	case CircuitStateClosed:
+		b.failures++
+		if err == nil {
+			b.failures = 0
+		}
+
+		if b.failures >= b.failureThreshold {
+			b.open()
+		}
+	case CircuitStateHalfOpen:
+		if !permit.probe || permit.halfOpened != b.halfOpened {
+			break
+		}
+
+		b.probing--
+		b.successes++
+
+		if err != nil {
+			b.open()
+		} else if b.successes >= b.halfOpenProbes {
+			b.state, b.failures = CircuitStateClosed, 0
+		}
+	case CircuitStateOpen:
+	}
+
+	current := b.state
+	b.mutex.Unlock()
+	b.notify(previous, current)
+}
+
+// Release releases the permit of a request that didn't reach the destination, without changing the state.
+func (b *CircuitBreaker) Release(permit CircuitPermit) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == CircuitStateHalfOpen && permit.probe && permit.halfOpened == b.halfOpened {
+		b.probing--
+	}
+}
+
+func (b *CircuitBreaker) State() CircuitState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.state
+}
+
+func (b *CircuitBreaker) open() {
+	b.state, b.openedAt = CircuitStateOpen, time.Now()
+}
+
+func (b *CircuitBreaker) notify(previous, current CircuitState) {
+	if previous != current && b.State() == current && b.onChange != nil {
+		b.onChange(current)
+	}
+}
+
+// recordDelivery records the result of the delivery in the circuit breaker, where only the transport errors and the
+// 5xx and 429 responses are failures of the destination, while the other responses are its successes. The deliveries
+// that didn't reach the destination, like the ones whose templates can't be rendered or that are cancelled, are left
+// out.
+func (r *Controller) recordDelivery(permit CircuitPermit, err error) {
+	var (
+		responseErr responseError
+		urlErr      *url.Error
+	)
+
+	switch {
+	case err == nil:
+		r.breaker.Record(permit, nil)
+	case errors.As(err, &responseErr):
+		if code := responseErr.statusCode(); code >= http.StatusInternalServerError || code == http.StatusTooManyRequests {
+			r.breaker.Record(permit, err)
+		} else {
+			r.breaker.Record(permit, nil)
+		}
+	case errors.As(err, &urlErr) && !errors.Is(err, context.Canceled):
+		r.breaker.Record(permit, err)
+	default:
+		r.breaker.Release(permit)
+	}
+}
@@ -0,0 +1,212 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	// given
+	var (
+		states  []CircuitState
+		failure = errors.New("failure")
+		breaker = NewCircuitBreaker(2, 50*time.Millisecond, 1, func(state CircuitState) {
+			states = append(states, state)
+		})
+	)
+
+	// when
+	breaker.Record(CircuitPermit{}, failure)
+	afterFirstFailure := breaker.State()
+	breaker.Record(CircuitPermit{}, failure)
+	_, openErr := breaker.Allow()
+	time.Sleep(60 * time.Millisecond)
+	probe, probeErr := breaker.Allow()
+	_, concurrentProbeErr := breaker.Allow()
+	breaker.Record(probe, failure)
+	_, reopenedErr := breaker.Allow()
+	time.Sleep(60 * time.Millisecond)
+	probe, _ = breaker.Allow()
+	breaker.Record(probe, nil)
+
+	// then
+	assert.Equal(t, CircuitStateClosed, afterFirstFailure)
+	assert.ErrorIs(t, openErr, ErrCircuitOpen)
+	assert.Nil(t, probeErr)
+	assert.ErrorIs(t, concurrentProbeErr, ErrCircuitOpen)
+	assert.ErrorIs(t, reopenedErr, ErrCircuitOpen)
+	assert.Equal(t, CircuitStateClosed, breaker.State())
+	assert.Equal(t, []CircuitState{
+		CircuitStateOpen, CircuitStateHalfOpen, CircuitStateOpen, CircuitStateHalfOpen, CircuitStateClosed,
+	}, states)
+}
+
+func TestCircuitBreaker_SuccessResetsFailures(t *testing.T) {
+	// given
+	var (
+		failure = errors.New("failure")
+		breaker = NewCircuitBreaker(2, time.Minute, 1, nil)
+	)
+
+	// when
+	breaker.Record(CircuitPermit{}, failure)
+	breaker.Record(CircuitPermit{}, nil)
+	breaker.Record(CircuitPermit{}, failure)
+	_, allowErr := breaker.Allow()
+
+	// then
+	assert.Equal(t, CircuitStateClosed, breaker.State())
+	assert.Nil(t, allowErr)
+}
+
+func TestCircuitBreaker_RecordOnlyProbes(t *testing.T) {
+	// given
+	var (
+		failure = errors.New("failure")
+		breaker = NewCircuitBreaker(1, 10*time.Millisecond, 1, nil)
+	)
+	closedPermit, _ := breaker.Allow()
+	breaker.Record(CircuitPermit{}, failure)
+	time.Sleep(20 * time.Millisecond)
+	probe, _ := breaker.Allow()
+
+	// when
+	breaker.Record(closedPermit, nil)
+	afterClosedRequest := breaker.State()
+	_, extraProbeErr := breaker.Allow()
+	breaker.Record(probe, nil)
+
+	// then
+	assert.Equal(t, CircuitStateHalfOpen, afterClosedRequest)
+	assert.ErrorIs(t, extraProbeErr, ErrCircuitOpen)
+	assert.Equal(t, CircuitStateClosed, breaker.State())
+	assert.Zero(t, breaker.probing)
+}
+
+func TestController_Reconcile_CircuitOpen(t *testing.T) {
+	// given
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		requests    = 0
+		server      = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Destination: v1alpha1.Destination{
+					URLTemplate: server.URL,
+					Method:      "POST",
+					CircuitBreaker: &v1alpha1.CircuitBreakerOptions{
+						FailureThreshold: ptr.To(1),
+						OpenDuration:     ptr.To("1m"),
+					},
+				},
+			},
+		}).Compile()
+		mockClient            = new(client2.MockClient)
+		mockSubResourceClient = new(client2.MockSubResourceClient)
+		controller            = NewController(mockClient, server.Client(), watcher)
+		request               = ctrl.Request{NamespacedName: types.NamespacedName{Name: "my-secret"}}
+		patchedStatus         = make(chan *unstructured.Unstructured, 1)
+		getObject             = func(ctx context.Context, key types.NamespacedName, obj client.Object,
+			opts ...client.GetOption,
+		) error {
+			return nil
+		}
+	)
+	defer server.Close()
+	defer cancel()
+	watcher.SetName("my-watcher")
+	mockClient.EXPECT().Get(mock.Anything, request.NamespacedName, mock.Anything).RunAndReturn(getObject)
+	mockClient.EXPECT().Status().Return(mockSubResourceClient)
+	mockSubResourceClient.EXPECT().Patch(mock.Anything, mock.Anything, client.Apply, mock.Anything,
+		mock.Anything).RunAndReturn(func(ctx context.Context, obj client.Object, patch client.Patch,
+		opts ...client.SubResourcePatchOption,
+	) error {
+		patchedStatus <- obj.(*unstructured.Unstructured)
+		return nil
+	})
+
+	go func() {
+		_ = controller.reportCircuitStates(ctx)
+	}()
+
+	// when
+	_, failedErr := controller.Reconcile(ctx, request)
+	result, openErr := controller.Reconcile(ctx, request)
+
+	// then
+	assert.ErrorIs(t, failedErr, ErrUnexpectedStatusCode)
+	assert.Nil(t, openErr)
+	assert.Greater(t, result.RequeueAfter, 50*time.Second)
+	assert.Equal(t, 1, requests)
+	status := <-patchedStatus
+	assert.Equal(t, "my-watcher", status.GetName())
+	conditions, _, _ := unstructured.NestedSlice(status.Object, "status", "conditions")
+	assert.Len(t, conditions, 1)
+	assert.Equal(t, v1alpha1.ConditionTypeDestinationAvailable, conditions[0].(map[string]interface{})["type"])
+	assert.Equal(t, "False", conditions[0].(map[string]interface{})["status"])
+}
+
+func TestController_RecordDelivery(t *testing.T) {
+	testCases := map[string]struct {
+		err   error
+		state CircuitState
+	}{
+		"server error":  {err: &StatusCodeError{StatusCode: http.StatusBadGateway}, state: CircuitStateOpen},
+		"throttled":     {err: &StatusCodeError{StatusCode: http.StatusTooManyRequests}, state: CircuitStateOpen},
+		"transport":     {err: &url.Error{Op: "Post", Err: errors.New("refused")}, state: CircuitStateOpen},
+		"client error":  {err: &StatusCodeError{StatusCode: http.StatusBadRequest}, state: CircuitStateClosed},
+		"rejected item": {err: &BulkItemError{Action: "index", StatusCode: 400}, state: CircuitStateClosed},
+		"render error":  {err: errors.New("template failed"), state: CircuitStateClosed},
+		"cancelled":     {err: &url.Error{Op: "Post", Err: context.Canceled}, state: CircuitStateClosed},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// given
+			controller := &Controller{breaker: NewCircuitBreaker(1, time.Minute, 1, nil)}
+			permit, _ := controller.breaker.Allow()
+
+			// when
+			controller.recordDelivery(permit, testCase.err)
+
+			// then
+			assert.Equal(t, testCase.state, controller.breaker.State())
+		})
+	}
+}
+
+func TestCircuitBreaker_Release(t *testing.T) {
+	// given
+	breaker := NewCircuitBreaker(1, 10*time.Millisecond, 1, nil)
+	breaker.Record(CircuitPermit{}, errors.New("failed"))
+	time.Sleep(20 * time.Millisecond)
+	probe, _ := breaker.Allow()
+
+	// when
+	_, busyErr := breaker.Allow()
+	breaker.Release(probe)
+	_, releasedErr := breaker.Allow()
+
+	// then
+	assert.ErrorIs(t, busyErr, ErrCircuitOpen)
+	assert.Nil(t, releasedErr)
+	assert.Equal(t, CircuitStateHalfOpen, breaker.State())
+}
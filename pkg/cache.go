@@ -0,0 +1,186 @@
+package pkg
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+)
+
+type selector interface {
+	Empty() bool
+	String() string
+}
+
+type watchedSource struct {
+	watcher *v1alpha1.Watcher
+	source  *v1alpha1.Source
+}
+
+// NewCacheByObject returns the cache options of the sources of the compiled watchers in the local cluster. The kinds
+// that aren't served are left out, since the cache can't be created with them, and their objects are filtered by
+// the controllers instead once they're served.
+func NewCacheByObject(watchers []*v1alpha1.Watcher,
+	discoveryClient discovery.ServerResourcesInterface,
+) map[client.Object]cache.ByObject {
+	return cacheByObject(watchers, discoveryClient, func(watcher *v1alpha1.Watcher, source *v1alpha1.Source) bool {
+		return clusterKey(watcher, source) == ""
+	})
+}
+
+// NewRelatedCache returns the cache of the cluster that the related objects of the filters, like the owners, the
+// referencing objects and the namespaces, are read from. It isn't restricted like the cache of the sources, whose
+// selectors would hide the related objects of the other watchers.
+func NewRelatedCache(c cluster.Cluster, syncPeriod *time.Duration) (cache.Cache, error) {
+	return cache.New(c.GetConfig(), cache.Options{
+		HTTPClient: c.GetHTTPClient(),
+		Scheme:     c.GetScheme(),
+		Mapper:     c.GetRESTMapper(),
+		SyncPeriod: syncPeriod,
+	})
+}
+
+// WithRelatedCache makes the related objects of the filters in the local cluster read from the cache.
+func (r *Controller) WithRelatedCache(relatedCache cache.Cache) *Controller {
+	r.relatedCache = relatedCache
+
+	return r
+}
+
+// relatedObjects returns the related cache of the cluster of the source, or the cache of the source without one.
+func (r *Controller) relatedObjects(mgr ctrl.Manager) cache.Cache {
+	if relatedCache := r.clusterRelatedCache(); relatedCache != nil {
+		return relatedCache
+	}
+
+	return r.sourceCache(mgr)
+}
+
+// clusterRelatedCache returns the related cache of the cluster of the source, which is nil when it isn't set up.
+func (r *Controller) clusterRelatedCache() cache.Cache {
+	if r.cluster != nil {
+		return r.cluster.relatedCache
+	}
+
+	return r.relatedCache
+}
+
+func cacheByObject(watchers []*v1alpha1.Watcher, discoveryClient discovery.ServerResourcesInterface,
+	inCluster func(*v1alpha1.Watcher, *v1alpha1.Source) bool,
+) map[client.Object]cache.ByObject {
+	var (
+		objects = map[schema.GroupVersionKind]client.Object{}
+		groups  = map[schema.GroupVersionKind][]watchedSource{}
+	)
+
+	for _, watcher := range watchers {
+		for index := range watcher.Spec.Compiled.Sources {
+			source := &watcher.Spec.Compiled.Sources[index]
+			if !inCluster(watcher, source) {
+				continue
+			}
+
+			gvk := source.GroupVersionKind()
+			objects[gvk] = source.NewObject()
+
+			groups[gvk] = append(groups[gvk], watchedSource{watcher: watcher, source: source})
+		}
+	}
+
+	byObject := map[client.Object]cache.ByObject{}
+
+	for gvk, object := range objects {
+		if served, _ := kindServed(discoveryClient, groups[gvk][0].source); !served {
+			continue
+		}
+
+		var (
+			labelSelectors = make([]labels.Selector, 0, len(groups[gvk]))
+			fieldSelectors = make([]fields.Selector, 0, len(groups[gvk]))
+		)
+
+		for _, watched := range groups[gvk] {
+			labelSelectors = append(labelSelectors, watched.watcher.Spec.Filter.Object.GetLabelSelector())
+			fieldSelectors = append(fieldSelectors, watched.source.GetFieldSelector())
+		}
+
+		options := cache.ByObject{
+			Namespaces: sharedNamespaces(groups[gvk]),
+			Label:      sharedSelector(labelSelectors),
+			Field:      sharedSelector(fieldSelectors),
+		}
+
+		if options.Namespaces != nil || options.Label != nil || options.Field != nil {
+			byObject[object] = options
+		}
+	}
+
+	return byObject
+}
+
+func (r *Controller) filterSource(obj *unstructured.Unstructured) bool {
+	source := r.source
+
+	if len(source.Namespaces) > 0 && !slices.Contains(source.Namespaces, obj.GetNamespace()) {
+		return true
+	}
+
+	return source.FieldSelector != nil &&
+		!source.Compiled.FieldSelector.Matches(fieldsFor(obj, source.Compiled.FieldSelector))
+}
+
+func fieldsFor(obj *unstructured.Unstructured, selector fields.Selector) fields.Set {
+	set := fields.Set{}
+
+	for _, requirement := range selector.Requirements() {
+		value, found, _ := unstructured.NestedFieldNoCopy(obj.Object, strings.Split(requirement.Field, ".")...)
+		if found && value != nil {
+			set[requirement.Field] = fmt.Sprint(value)
+		}
+	}
+
+	return set
+}
+
+func sharedNamespaces(watched []watchedSource) map[string]cache.Config {
+	namespaces := map[string]cache.Config{}
+
+	for _, current := range watched {
+		if len(current.source.Namespaces) == 0 {
+			return nil
+		}
+
+		for _, namespace := range current.source.Namespaces {
+			namespaces[namespace] = cache.Config{}
+		}
+	}
+
+	return namespaces
+}
+
+func sharedSelector[T selector](selectors []T) T {
+	var none T
+
+	if len(selectors) == 0 {
+		return none
+	}
+
+	for _, current := range selectors {
+		if current.Empty() || current.String() != selectors[0].String() {
+			return none
+		}
+	}
+
+	return selectors[0]
+}
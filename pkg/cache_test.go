@@ -0,0 +1,162 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	cache2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/cache"
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/manager"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+func TestNewCacheByObject(t *testing.T) {
+	// given
+	var (
+		newWatcher = func(kind string, filter v1alpha1.ObjectFilter) *v1alpha1.Watcher {
+			return (&v1alpha1.Watcher{
+				Spec: v1alpha1.WatcherSpec{
+					Source: v1alpha1.Source{APIVersion: "v1", Kind: kind},
+					Filter: v1alpha1.Filter{Object: filter},
+				},
+			}).Compile()
+		}
+		newSourceWatcher = func(kind string, source v1alpha1.Source) *v1alpha1.Watcher {
+			source.APIVersion, source.Kind = "v1", kind
+
+			return (&v1alpha1.Watcher{Spec: v1alpha1.WatcherSpec{Source: source}}).Compile()
+		}
+		selectorFilter = v1alpha1.ObjectFilter{
+			Labels: ptr.To(map[string]string{"app": "web"}),
+			LabelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "tier", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"db"}},
+				},
+			},
+		}
+		watchers = []*v1alpha1.Watcher{
+			newWatcher("Secret", selectorFilter),
+			newWatcher("Secret", selectorFilter),
+			newWatcher("ConfigMap", selectorFilter),
+			newWatcher("ConfigMap", v1alpha1.ObjectFilter{}),
+			newWatcher("Pod", v1alpha1.ObjectFilter{}),
+			newSourceWatcher("Event", v1alpha1.Source{Namespaces: []string{"default"}}),
+			newSourceWatcher("Event", v1alpha1.Source{Namespaces: []string{"kube-system", "default"}}),
+			newSourceWatcher("Node", v1alpha1.Source{FieldSelector: ptr.To("spec.unschedulable=true")}),
+			newSourceWatcher("Node", v1alpha1.Source{FieldSelector: ptr.To("spec.unschedulable=true")}),
+			newSourceWatcher("Endpoints", v1alpha1.Source{Namespaces: []string{"default"}}),
+			newSourceWatcher("Endpoints", v1alpha1.Source{}),
+			(&v1alpha1.Watcher{Spec: v1alpha1.WatcherSpec{Source: v1alpha1.Source{
+				APIVersion: "example.com/v1", Kind: "Widget", Namespaces: []string{"default"},
+			}}}).Compile(),
+		}
+		discoveryClient = &fake.FakeDiscovery{Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{
+					{Name: "secrets", Kind: "Secret"},
+					{Name: "configmaps", Kind: "ConfigMap"},
+					{Name: "pods", Kind: "Pod"},
+					{Name: "events", Kind: "Event"},
+					{Name: "nodes", Kind: "Node"},
+					{Name: "endpoints", Kind: "Endpoints"},
+				},
+			}},
+		}}
+	)
+
+	// when
+	byObject := NewCacheByObject(watchers, discoveryClient)
+
+	// then
+	assert.Len(t, byObject, 3)
+
+	for object, options := range byObject {
+		switch object.(*unstructured.Unstructured).GetKind() {
+		case "Secret":
+			assert.Equal(t, "app=web,tier notin (db)", options.Label.String())
+			assert.Nil(t, options.Namespaces)
+		case "Event":
+			assert.Equal(t, map[string]cache.Config{"default": {}, "kube-system": {}}, options.Namespaces)
+			assert.Nil(t, options.Field)
+		case "Node":
+			assert.Equal(t, "spec.unschedulable=true", options.Field.String())
+			assert.Nil(t, options.Label)
+		default:
+			assert.Fail(t, "unexpected object", object)
+		}
+	}
+}
+
+func TestController_FilterObject_Source(t *testing.T) {
+	// given
+	var (
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{
+					Namespaces:    []string{"default"},
+					FieldSelector: ptr.To("status.phase=Running,spec.nodeName!=node-1"),
+				},
+			},
+		}).Compile()
+		controller = NewController(new(client2.MockClient), &http.Client{}, watcher)
+		newPod     = func(namespace, phase, nodeName string) *unstructured.Unstructured {
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"namespace": namespace},
+				"spec":     map[string]interface{}{"nodeName": nodeName},
+				"status":   map[string]interface{}{"phase": phase},
+			}}
+		}
+	)
+
+	// when
+	matched, _ := controller.FilterObject(context.Background(), newPod("default", "Running", "node-2"))
+	otherNamespace, _ := controller.FilterObject(context.Background(), newPod("kube-system", "Running", "node-2"))
+	pending, _ := controller.FilterObject(context.Background(), newPod("default", "Pending", "node-2"))
+	excludedNode, _ := controller.FilterObject(context.Background(), newPod("default", "Running", "node-1"))
+
+	// then
+	assert.False(t, matched)
+	assert.True(t, otherNamespace)
+	assert.True(t, pending)
+	assert.True(t, excludedNode)
+}
+
+func TestController_RelatedObjects(t *testing.T) {
+	// given
+	var (
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "v1", Kind: "Pod"},
+				Filter: v1alpha1.Filter{Object: v1alpha1.ObjectFilter{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				}},
+			},
+		}).Compile()
+		mockManager       = new(manager.MockManager)
+		sourceCache       = new(cache2.MockCache)
+		relatedCache      = new(cache2.MockCache)
+		controller        = NewController(new(client2.MockClient), &http.Client{}, watcher)
+		relatedController = NewController(new(client2.MockClient), &http.Client{}, watcher).
+					WithRelatedCache(relatedCache)
+	)
+	mockManager.EXPECT().GetCache().Return(sourceCache)
+
+	// when
+	withoutRelatedCache := controller.relatedObjects(mockManager)
+	withRelatedCache := relatedController.relatedObjects(mockManager)
+	forSource := relatedController.ForSource(&watcher.Spec.Compiled.Sources[0]).relatedObjects(mockManager)
+
+	// then
+	assert.Same(t, sourceCache, withoutRelatedCache)
+	assert.Same(t, relatedCache, withRelatedCache)
+	assert.Same(t, relatedCache, forSource)
+}
@@ -0,0 +1,229 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+)
+
+var ErrClusterNotFound = errors.New("cluster not found")
+
+// RemoteCluster is a cluster that the objects of the sources referencing its kubeconfig are watched in, or the cluster
+// that Watchtower runs in when the sources are watched as the service account of their watcher.
+type RemoteCluster struct {
+	cluster.Cluster
+	// relatedCache is the cache of the related objects of the filters in the cluster.
+	relatedCache cache.Cache
+
+	key       string
+	config    *rest.Config
+	discovery discovery.DiscoveryInterface
+}
+
+// NewRemoteClusters reads the kubeconfigs of the clusters referenced by the sources of the compiled watchers, and
+// impersonates the service accounts of the watchers on them or on the local config, keyed by clusterKey. The clusters
+// that can't be read are left out and their errors are joined.
+func NewRemoteClusters(ctx context.Context, reader client.Reader, localConfig *rest.Config,
+	watchers []*v1alpha1.Watcher,
+) (map[string]*RemoteCluster, error) {
+	var (
+		clusters = map[string]*RemoteCluster{}
+		failed   = map[string]bool{}
+		errs     []error
+	)
+
+	for _, watcher := range watchers {
+		for _, source := range watcher.Spec.Compiled.Sources {
+			key := clusterKey(watcher, &source)
+			if key == "" || clusters[key] != nil || failed[key] {
+				continue
+			}
+
+			remote, newErr := newRemoteCluster(ctx, reader, localConfig, watcher, &source)
+			if newErr != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, newErr))
+				failed[key] = true
+
+				continue
+			}
+
+			clusters[key] = remote
+		}
+	}
+
+	return clusters, errors.Join(errs...)
+}
+
+// KubeConfigVersions returns the resource versions of the kubeconfig secrets of the sources of the watchers, keyed by
+// the secrets, so that the rotated kubeconfigs can be noticed. The secrets that can't be read have no version.
+func KubeConfigVersions(ctx context.Context, reader client.Reader, watchers []v1alpha1.Watcher) map[string]string {
+	versions := map[string]string{}
+
+	for _, watcher := range watchers {
+		for _, source := range append([]v1alpha1.Source{watcher.Spec.Source}, watcher.Spec.Sources...) {
+			if source.Cluster == nil {
+				continue
+			}
+
+			var (
+				kubeConfig = source.Cluster.KubeConfig
+				secret     metav1.PartialObjectMetadata
+			)
+
+			secret.SetGroupVersionKind(v1.SchemeGroupVersion.WithKind("Secret"))
+
+			if getErr := reader.Get(ctx, client.ObjectKey{
+				Name: kubeConfig.Name, Namespace: kubeConfig.Namespace,
+			}, &secret); getErr == nil {
+				versions[kubeConfig.String()] = secret.GetResourceVersion()
+			}
+		}
+	}
+
+	return versions
+}
+
+func newRemoteCluster(ctx context.Context, reader client.Reader, localConfig *rest.Config,
+	watcher *v1alpha1.Watcher, source *v1alpha1.Source,
+) (*RemoteCluster, error) {
+	config := rest.CopyConfig(localConfig)
+
+	if source.Cluster != nil {
+		kubeConfig := source.Cluster.KubeConfig
+
+		var secret v1.Secret
+		if getErr := reader.Get(ctx, client.ObjectKey{
+			Name: kubeConfig.Name, Namespace: kubeConfig.Namespace,
+		}, &secret); getErr != nil {
+			return nil, getErr
+		}
+
+		remoteConfig, configErr := clientcmd.RESTConfigFromKubeConfig(secret.Data[kubeConfig.Key])
+		if configErr != nil {
+			return nil, configErr
+		}
+
+		config = remoteConfig
+	}
+
+	if user := serviceAccountUser(watcher); user != "" {
+		config.Impersonate = rest.ImpersonationConfig{UserName: user}
+	}
+
+	discoveryClient, discoveryErr := discovery.NewDiscoveryClientForConfig(config)
+	if discoveryErr != nil {
+		return nil, discoveryErr
+	}
+
+	return &RemoteCluster{key: clusterKey(watcher, source), config: config, discovery: discoveryClient}, nil
+}
+
+// clusterKey identifies the cluster that the source is watched in and the user that it's watched as, which is empty
+// when it's watched in the cluster that Watchtower runs in as its own service account.
+func clusterKey(watcher *v1alpha1.Watcher, source *v1alpha1.Source) string {
+	key := ""
+	if source.Cluster != nil {
+		key = source.Cluster.KubeConfig.String()
+	}
+
+	if user := serviceAccountUser(watcher); user != "" {
+		key += "@" + user
+	}
+
+	return key
+}
+
+// serviceAccountUser is the user name of the service account of the watcher that is impersonated.
+func serviceAccountUser(watcher *v1alpha1.Watcher) string {
+	namespace, name := watcher.ServiceAccount()
+	if name == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name)
+}
+
+// Setup creates the cluster with a cache for the sources of the compiled watchers in it, and runs it with the manager.
+func (c *RemoteCluster) Setup(mgr ctrl.Manager, cacheOptions cache.Options, watchers []*v1alpha1.Watcher) error {
+	cacheOptions.ByObject = cacheByObject(watchers, c.discovery,
+		func(watcher *v1alpha1.Watcher, source *v1alpha1.Source) bool {
+			return clusterKey(watcher, source) == c.key
+		})
+
+	remote, newErr := cluster.New(c.config, func(options *cluster.Options) {
+		options.Scheme = mgr.GetScheme()
+		options.Logger = mgr.GetLogger().WithValues("cluster", c.key)
+		options.Cache = cacheOptions
+	})
+	if newErr != nil {
+		return newErr
+	}
+
+	relatedCache, relatedErr := NewRelatedCache(remote, cacheOptions.SyncPeriod)
+	if relatedErr != nil {
+		return relatedErr
+	}
+
+	c.Cluster, c.relatedCache = remote, relatedCache
+
+	if addErr := mgr.Add(relatedCache); addErr != nil {
+		return addErr
+	}
+
+	return mgr.Add(remote)
+}
+
+// WithClusters makes the sources referencing the remote clusters, or watched as a service account, watch the objects
+// in their clusters.
+func (r *Controller) WithClusters(clusters map[string]*RemoteCluster) *Controller {
+	r.clusters = clusters
+
+	return r
+}
+
+func (r *Controller) remoteCluster(source *v1alpha1.Source) (*RemoteCluster, error) {
+	key := clusterKey(r.watcher, source)
+	if remote := r.clusters[key]; remote != nil && remote.Cluster != nil {
+		return remote, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrClusterNotFound, key)
+}
+
+func (r *Controller) sourceCache(mgr ctrl.Manager) cache.Cache {
+	if r.cluster != nil {
+		return r.cluster.GetCache()
+	}
+
+	return mgr.GetCache()
+}
+
+func (r *Controller) setClusterReachable(ctx context.Context, reachable bool) error {
+	unreachable := r.unreachable.set(r.source.Cluster.GetName(), !reachable)
+
+	condition := metav1.Condition{
+		Type:    v1alpha1.ConditionTypeClustersReachable,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ClustersReachable",
+		Message: "The remote clusters of all sources are reachable.",
+	}
+
+	if len(unreachable) > 0 {
+		condition.Status, condition.Reason, condition.Message = metav1.ConditionFalse, "ClustersUnreachable",
+			"Unable to reach the clusters: "+strings.Join(unreachable, ", ")
+	}
+
+	return r.SetCondition(ctx, condition)
+}
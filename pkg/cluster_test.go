@@ -0,0 +1,511 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	cache2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/cache"
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/manager"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	controller2 "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	manager2 "sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: remote
+  cluster:
+    server: https://remote.example.com
+contexts:
+- name: remote
+  context:
+    cluster: remote
+    user: remote
+current-context: remote
+users:
+- name: remote
+  user:
+    token: my-token
+`
+
+func TestNewRemoteClusters(t *testing.T) {
+	// given
+	var (
+		ctx        = context.Background()
+		mockClient = new(client2.MockClient)
+		kubeConfig = v1alpha1.SecretKeySelector{Name: "remote", Namespace: "default", Key: "kubeconfig"}
+		missing    = v1alpha1.SecretKeySelector{Name: "missing", Namespace: "default", Key: "kubeconfig"}
+		watchers   = []*v1alpha1.Watcher{
+			(&v1alpha1.Watcher{Spec: v1alpha1.WatcherSpec{Sources: []v1alpha1.Source{
+				{APIVersion: "v1", Kind: "Secret", Cluster: &v1alpha1.ClusterSource{KubeConfig: kubeConfig}},
+				{APIVersion: "v1", Kind: "ConfigMap"},
+			}}}).Compile(),
+			(&v1alpha1.Watcher{Spec: v1alpha1.WatcherSpec{Sources: []v1alpha1.Source{
+				{APIVersion: "v1", Kind: "ConfigMap", Cluster: &v1alpha1.ClusterSource{KubeConfig: kubeConfig}},
+				{APIVersion: "v1", Kind: "Secret", Cluster: &v1alpha1.ClusterSource{KubeConfig: missing}},
+			}}}).Compile(),
+		}
+	)
+	mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "remote", Namespace: "default"},
+		mock.AnythingOfType("*v1.Secret")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			obj.(*v1.Secret).Data = map[string][]byte{"kubeconfig": []byte(testKubeConfig)}
+
+			return nil
+		}).Once()
+	mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "missing", Namespace: "default"},
+		mock.AnythingOfType("*v1.Secret")).Return(
+		apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "missing")).Once()
+
+	// when
+	clusters, clustersErr := NewRemoteClusters(ctx, mockClient, &rest.Config{}, watchers)
+
+	// then
+	assert.ErrorContains(t, clustersErr, "default/missing/kubeconfig")
+	assert.True(t, apierrors.IsNotFound(clustersErr))
+	assert.Len(t, clusters, 1)
+	assert.Equal(t, "https://remote.example.com", clusters["default/remote/kubeconfig"].config.Host)
+	assert.Equal(t, "my-token", clusters["default/remote/kubeconfig"].config.BearerToken)
+}
+
+func TestNewRemoteClusters_ServiceAccount(t *testing.T) {
+	// given
+	var (
+		localConfig = &rest.Config{Host: "https://local.example.com", BearerToken: "watchtower-token"}
+		watcher     = (&v1alpha1.Watcher{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-watcher", Namespace: "team-a"},
+			Spec: v1alpha1.WatcherSpec{
+				Source:             v1alpha1.Source{APIVersion: "v1", Kind: "ConfigMap", Namespaces: []string{"team-a"}},
+				ServiceAccountName: ptr.To("reader"),
+			},
+		}).Compile()
+		key = "@system:serviceaccount:team-a:reader"
+	)
+
+	// when
+	clusters, clustersErr := NewRemoteClusters(context.Background(), new(client2.MockClient), localConfig,
+		[]*v1alpha1.Watcher{watcher})
+
+	// then
+	assert.Nil(t, clustersErr)
+	assert.Len(t, clusters, 1)
+	assert.Equal(t, "https://local.example.com", clusters[key].config.Host)
+	assert.Equal(t, "system:serviceaccount:team-a:reader", clusters[key].config.Impersonate.UserName)
+	assert.Empty(t, localConfig.Impersonate.UserName)
+	assert.Empty(t, NewCacheByObject([]*v1alpha1.Watcher{watcher}, &fake.FakeDiscovery{Fake: &clienttesting.Fake{}}))
+}
+
+func TestController_SetupWithManager_ClusterNotFound(t *testing.T) {
+	// given
+	var (
+		mockClient  = new(client2.MockClient)
+		mockManager = new(manager.MockManager)
+		mockCache   = new(cache2.MockCache)
+		watcher     = (&v1alpha1.Watcher{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-watcher"},
+			Spec: v1alpha1.WatcherSpec{Sources: []v1alpha1.Source{
+				{APIVersion: "v1", Kind: "ConfigMap"},
+				{APIVersion: "v1", Kind: "Secret", Cluster: &v1alpha1.ClusterSource{
+					KubeConfig: v1alpha1.SecretKeySelector{Name: "remote", Namespace: "default", Key: "kubeconfig"},
+				}},
+			}},
+		}).Compile()
+		controller = NewController(mockClient, &http.Client{}, watcher).WithClusters(map[string]*RemoteCluster{
+			// The cluster couldn't be set up, so it has no cache.
+			"default/remote/kubeconfig": {key: "default/remote/kubeconfig"},
+		})
+		mockSubResourceClient = new(client2.MockSubResourceClient)
+		reported              manager2.Runnable
+	)
+
+	mockManager.EXPECT().GetControllerOptions().Return(config.Controller{})
+	mockManager.EXPECT().GetScheme().Return(runtime.NewScheme())
+	mockManager.EXPECT().GetCache().Return(mockCache)
+	mockManager.EXPECT().GetAPIReader().Return(mockClient)
+	mockManager.EXPECT().GetLogger().Return(zap.New())
+	mockManager.EXPECT().Add(mock.MatchedBy(func(ct controller2.Controller) bool {
+		return ct != nil
+	})).Return(nil).Once()
+	mockManager.EXPECT().Add(mock.Anything).RunAndReturn(func(runnable manager2.Runnable) error {
+		reported = runnable
+
+		return nil
+	}).Once()
+	mockClient.EXPECT().Status().Return(mockSubResourceClient)
+	mockSubResourceClient.EXPECT().Patch(mock.Anything, mock.MatchedBy(func(obj client.Object) bool {
+		conditions, _, _ := unstructured.NestedSlice(obj.(*unstructured.Unstructured).Object, "status", "conditions")
+
+		return conditions[0].(map[string]any)["type"] == v1alpha1.ConditionTypeClustersReachable &&
+			conditions[0].(map[string]any)["status"] == string(metav1.ConditionFalse)
+	}), client.Apply, mock.Anything, mock.Anything).Return(nil)
+
+	// when
+	setupErr := controller.SetupWithManager(mockManager)
+	reportErr := reported.Start(context.Background())
+
+	// then
+	assert.ErrorIs(t, setupErr, ErrClusterNotFound)
+	assert.Nil(t, reportErr)
+	mockManager.AssertNumberOfCalls(t, "Add", 2)
+}
+
+func TestController_TemplateData_Cluster(t *testing.T) {
+	// given
+	var (
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{Source: v1alpha1.Source{
+				APIVersion: "v1", Kind: "Secret", Cluster: &v1alpha1.ClusterSource{
+					Name:       ptr.To("production"),
+					KubeConfig: v1alpha1.SecretKeySelector{Name: "remote", Namespace: "default", Key: "kubeconfig"},
+				},
+			}},
+		}).Compile()
+		controller = NewController(new(client2.MockClient), &http.Client{}, watcher)
+		secret     = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "my-secret", "namespace": "default"},
+		}}
+	)
+
+	// when
+	data := controller.TemplateData(secret)
+	body, marshalErr := data.MarshalJSON()
+
+	// then
+	assert.Equal(t, "production", data[TemplateKeyCluster])
+	assert.Nil(t, marshalErr)
+	assert.NotContains(t, string(body), "production")
+}
+
+func TestSourceRunner_SyncReachable(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		watcher = (&v1alpha1.Watcher{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-watcher"},
+			Spec: v1alpha1.WatcherSpec{Source: v1alpha1.Source{
+				APIVersion: "v1", Kind: "Secret", Cluster: &v1alpha1.ClusterSource{
+					KubeConfig: v1alpha1.SecretKeySelector{Name: "remote", Namespace: "default", Key: "kubeconfig"},
+				},
+			}},
+		}).Compile()
+		mockClient            = new(client2.MockClient)
+		mockSubResourceClient = new(client2.MockSubResourceClient)
+		controller            = NewController(mockClient, &http.Client{}, watcher)
+		runner                = &sourceRunner{controller: controller}
+		messages              []string
+	)
+	controller.cluster = &RemoteCluster{key: "default/remote/kubeconfig"}
+	mockClient.EXPECT().Status().Return(mockSubResourceClient)
+	mockSubResourceClient.EXPECT().Patch(mock.Anything, mock.Anything, client.Apply, mock.Anything,
+		mock.Anything).RunAndReturn(func(ctx context.Context, obj client.Object, patch client.Patch,
+		opts ...client.SubResourcePatchOption,
+	) error {
+		conditions, _, _ := unstructured.NestedSlice(obj.(*unstructured.Unstructured).Object, "status", "conditions")
+		messages = append(messages, conditions[0].(map[string]interface{})["message"].(string))
+
+		return nil
+	})
+
+	// when
+	unreachableErr := runner.syncReachable(ctx, false)
+	unchangedErr := runner.syncReachable(ctx, false)
+	reachableErr := runner.syncReachable(ctx, true)
+
+	// then
+	assert.Nil(t, unreachableErr)
+	assert.Nil(t, unchangedErr)
+	assert.Nil(t, reachableErr)
+	assert.Equal(t, []string{
+		"Unable to reach the clusters: remote",
+		"The remote clusters of all sources are reachable.",
+	}, messages)
+}
+
+func TestController_ReconcileRemoteClusterIntegration(t *testing.T) {
+	// given
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var body []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte("OK"))
+	}))
+
+	remoteEnv := &envtest.Environment{
+		BinaryAssetsDirectory:    "../.envtest/bins",
+		ControlPlaneStartTimeout: 5 * time.Minute,
+		ControlPlaneStopTimeout:  5 * time.Minute,
+	}
+
+	remoteConfig, remoteStartErr := remoteEnv.Start()
+	if remoteStartErr != nil {
+		panic(remoteStartErr)
+	}
+
+	defer remoteEnv.Stop()
+
+	remoteUser, userErr := remoteEnv.ControlPlane.AddUser(envtest.User{
+		Name: "watchtower", Groups: []string{"system:masters"},
+	}, remoteConfig)
+	if userErr != nil {
+		panic(userErr)
+	}
+
+	kubeConfig, kubeConfigErr := remoteUser.KubeConfig()
+	if kubeConfigErr != nil {
+		panic(kubeConfigErr)
+	}
+
+	localClient, localClientErr := client.New(testVars.kubeConfig, client.Options{Scheme: testVars.scheme})
+	if localClientErr != nil {
+		panic(localClientErr)
+	}
+
+	remoteClient, remoteClientErr := client.New(remoteConfig, client.Options{Scheme: testVars.scheme})
+	if remoteClientErr != nil {
+		panic(remoteClientErr)
+	}
+
+	kubeConfigSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: uuid.NewString(), Namespace: "default"},
+		Data:       map[string][]byte{"kubeconfig": kubeConfig},
+	}
+	if createErr := localClient.Create(ctx, kubeConfigSecret); createErr != nil {
+		panic(createErr)
+	}
+
+	watcher := (&v1alpha1.Watcher{
+		ObjectMeta: metav1.ObjectMeta{Name: uuid.NewString()},
+		Spec: v1alpha1.WatcherSpec{
+			Source: v1alpha1.Source{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Namespaces: []string{"default"},
+				Cluster: &v1alpha1.ClusterSource{
+					Name: ptr.To("production"),
+					KubeConfig: v1alpha1.SecretKeySelector{
+						Name: kubeConfigSecret.GetName(), Namespace: "default", Key: "kubeconfig",
+					},
+				},
+			},
+			Filter: v1alpha1.Filter{
+				Object: v1alpha1.ObjectFilter{Name: ptr.To("my-remote-configmap")},
+			},
+			Destination: v1alpha1.Destination{
+				URLTemplate:  server.URL,
+				BodyTemplate: "{{ .cluster }}/{{ .metadata.name }}",
+				Method:       "POST",
+			},
+		},
+	}).Compile()
+
+	manager, managerErr := ctrl.NewManager(testVars.kubeConfig, ctrl.Options{
+		Scheme: testVars.scheme, Logger: zap.New(),
+	})
+	if managerErr != nil {
+		panic(managerErr)
+	}
+
+	clusters, clustersErr := NewRemoteClusters(ctx, localClient, testVars.kubeConfig, []*v1alpha1.Watcher{watcher})
+	if clustersErr != nil {
+		panic(clustersErr)
+	}
+
+	for _, remote := range clusters {
+		if setupErr := remote.Setup(manager, cache.Options{}, []*v1alpha1.Watcher{watcher}); setupErr != nil {
+			panic(setupErr)
+		}
+	}
+
+	if setupErr := NewController(manager.GetClient(), server.Client(), watcher).WithClusters(clusters).
+		SetupWithManager(manager); setupErr != nil {
+		panic(setupErr)
+	}
+
+	go func() {
+		if managerStartErr := manager.Start(ctx); managerStartErr != nil {
+			panic(managerStartErr)
+		}
+	}()
+
+	// when
+	createErr := remoteClient.Create(ctx, &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-remote-configmap", Namespace: "default"},
+	})
+
+	// then
+	assert.Nil(t, createErr)
+	assert.Eventually(t, func() bool {
+		return string(body) == fmt.Sprintf("%s/%s", "production", "my-remote-configmap")
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestController_ReconcileServiceAccountIntegration(t *testing.T) {
+	// given
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var bodies []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.Write([]byte("OK"))
+	}))
+
+	localClient, localClientErr := client.New(testVars.kubeConfig, client.Options{Scheme: testVars.scheme})
+	if localClientErr != nil {
+		panic(localClientErr)
+	}
+
+	var (
+		namespace = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-" + uuid.NewString()[:8]}}
+		reader    = &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: namespace.GetName()}}
+		role      = &rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: namespace.GetName()},
+			Rules: []rbacv1.PolicyRule{{
+				APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "list", "watch"},
+			}},
+		}
+		roleBinding = &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: namespace.GetName()},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "reader"},
+			Subjects: []rbacv1.Subject{{
+				Kind: rbacv1.ServiceAccountKind, Name: "reader", Namespace: namespace.GetName(),
+			}},
+		}
+	)
+
+	for _, obj := range []client.Object{namespace, reader, role, roleBinding} {
+		if createErr := localClient.Create(ctx, obj); createErr != nil {
+			panic(createErr)
+		}
+	}
+
+	namespacedWatcher := &v1alpha1.NamespacedWatcher{
+		ObjectMeta: metav1.ObjectMeta{Name: uuid.NewString(), Namespace: namespace.GetName()},
+		Spec: v1alpha1.WatcherSpec{
+			Source:             v1alpha1.Source{APIVersion: "v1", Kind: "ConfigMap"},
+			ServiceAccountName: ptr.To("reader"),
+			Destination: v1alpha1.Destination{
+				URLTemplate:  server.URL,
+				BodyTemplate: "{{ .metadata.name }}",
+				Method:       "POST",
+			},
+		},
+	}
+	asWatcher := namespacedWatcher.AsWatcher()
+
+	if restrictErr := RestrictToNamespace(&asWatcher); restrictErr != nil {
+		panic(restrictErr)
+	}
+
+	watcher := asWatcher.Compile()
+
+	manager, managerErr := ctrl.NewManager(testVars.kubeConfig, ctrl.Options{
+		Scheme: testVars.scheme, Logger: zap.New(),
+	})
+	if managerErr != nil {
+		panic(managerErr)
+	}
+
+	clusters, clustersErr := NewRemoteClusters(ctx, localClient, testVars.kubeConfig, []*v1alpha1.Watcher{watcher})
+	if clustersErr != nil {
+		panic(clustersErr)
+	}
+
+	for _, remote := range clusters {
+		if setupErr := remote.Setup(manager, cache.Options{}, []*v1alpha1.Watcher{watcher}); setupErr != nil {
+			panic(setupErr)
+		}
+	}
+
+	if setupErr := NewController(manager.GetClient(), server.Client(), watcher).WithClusters(clusters).
+		SetupWithManager(manager); setupErr != nil {
+		panic(setupErr)
+	}
+
+	go func() {
+		if managerStartErr := manager.Start(ctx); managerStartErr != nil {
+			panic(managerStartErr)
+		}
+	}()
+
+	// when
+	createErr := localClient.Create(ctx, &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-tenant-configmap", Namespace: namespace.GetName()},
+	})
+
+	// then
+	assert.Nil(t, createErr)
+	assert.Eventually(t, func() bool {
+		return slices.Contains(bodies, "my-tenant-configmap")
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.Equal(t, "system:serviceaccount:"+namespace.GetName()+":reader",
+		clusters["@system:serviceaccount:"+namespace.GetName()+":reader"].config.Impersonate.UserName)
+}
+
+func TestKubeConfigVersions(t *testing.T) {
+	// given
+	var (
+		mockClient = new(client2.MockClient)
+		newSource  = func(name string) v1alpha1.Source {
+			return v1alpha1.Source{APIVersion: "v1", Kind: "Secret", Cluster: &v1alpha1.ClusterSource{
+				KubeConfig: v1alpha1.SecretKeySelector{Name: name, Namespace: "default", Key: "kubeconfig"},
+			}}
+		}
+		watchers = []v1alpha1.Watcher{
+			{Spec: v1alpha1.WatcherSpec{Source: newSource("production")}},
+			{Spec: v1alpha1.WatcherSpec{Sources: []v1alpha1.Source{{APIVersion: "v1", Kind: "Pod"}, newSource("missing")}}},
+		}
+	)
+	mockClient.EXPECT().Get(mock.Anything, types.NamespacedName{Namespace: "default", Name: "production"},
+		mock.AnythingOfType("*v1.PartialObjectMetadata")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			obj.SetResourceVersion("2")
+
+			return nil
+		})
+	mockClient.EXPECT().Get(mock.Anything, types.NamespacedName{Namespace: "default", Name: "missing"},
+		mock.AnythingOfType("*v1.PartialObjectMetadata")).
+		Return(apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "missing"))
+
+	// when
+	versions := KubeConfigVersions(context.Background(), mockClient, watchers)
+
+	// then
+	assert.Equal(t, map[string]string{"default/production/kubeconfig": "2"}, versions)
+}
@@ -0,0 +1,120 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apiserver/pkg/cel/environment"
+)
+
+const (
+	CELVariableObject    = "object"
+	CELVariableOldObject = "oldObject"
+	CELVariableEventType = "eventType"
+
+	celInterruptCheckFrequency = 100
+)
+
+var (
+	ErrCELResultNotBool    = errors.New("cel expression must evaluate to a bool")
+	ErrCELOldObjectMissing = errors.New("cel expression read the fields of a missing oldObject")
+
+	celEnvironment = sync.OnceValues(newCELEnvironment)
+)
+
+// CELProgram is the compiled CEL expression keeping Its syntax tree to find the expressions failing.
+type CELProgram struct {
+	cel.Program
+
+	ast *ast.AST
+}
+
+func CELParse(expression string) *CELProgram {
+	env := MustReturn(celEnvironment())
+
+	checked, issues := env.Compile(expression)
+	Must(issues.Err())
+
+	if checked.OutputType() != cel.BoolType {
+		panic(fmt.Errorf("%w: %s is %s", ErrCELResultNotBool, expression, checked.OutputType()))
+	}
+
+	return &CELProgram{Program: MustReturn(env.Program(checked)), ast: checked.NativeRep()}
+}
+
+// CELEvaluate evaluates the program with the variables, the errors of reading the fields of a null oldObject are
+// wrapped with ErrCELOldObjectMissing.
+func CELEvaluate(ctx context.Context, program *CELProgram, variables map[string]any) (bool, error) {
+	result, _, evalErr := program.ContextEval(ctx, variables)
+	if evalErr != nil {
+		if variables[CELVariableOldObject] == nil && program.readsVariable(evalErr, CELVariableOldObject) {
+			return false, fmt.Errorf("%w: %w", ErrCELOldObjectMissing, evalErr)
+		}
+
+		return false, evalErr
+	}
+
+	matched, isBool := result.Value().(bool)
+	if !isBool {
+		return false, fmt.Errorf("%w: %v", ErrCELResultNotBool, result.Value())
+	}
+
+	return matched, nil
+}
+
+// readsVariable returns whether the evaluation error is raised by a field or an index of the variable.
+func (p *CELProgram) readsVariable(evalErr error, variable string) bool {
+	var celErr *types.Err
+	if !errors.As(evalErr, &celErr) || celErr.NodeID() == 0 {
+		return false
+	}
+
+	failed := ast.MatchDescendants(ast.NavigateAST(p.ast), func(expr ast.NavigableExpr) bool {
+		return expr.ID() == celErr.NodeID()
+	})
+	if len(failed) == 0 {
+		return false
+	}
+
+	for expr := ast.Expr(failed[0]); ; {
+		switch {
+		case expr.Kind() == ast.SelectKind:
+			expr = expr.AsSelect().Operand()
+		case expr.Kind() == ast.CallKind && isIndexOperator(expr.AsCall().FunctionName()):
+			expr = expr.AsCall().Args()[0]
+		default:
+			return expr.Kind() == ast.IdentKind && expr.AsIdent() == variable
+		}
+	}
+}
+
+func isIndexOperator(function string) bool {
+	return function == operators.Index || function == operators.OptIndex || function == operators.OptSelect
+}
+
+func newCELEnvironment() (*cel.Env, error) {
+	envSet, extendErr := environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true).
+		Extend(environment.VersionedOptions{
+			IntroducedVersion: version.MajorMinor(1, 0),
+			EnvOptions: []cel.EnvOption{
+				cel.Variable(CELVariableObject, cel.DynType),
+				cel.Variable(CELVariableOldObject, cel.DynType),
+				cel.Variable(CELVariableEventType, cel.StringType),
+			},
+			ProgramOptions: []cel.ProgramOption{
+				cel.InterruptCheckFrequency(celInterruptCheckFrequency),
+			},
+		})
+	if extendErr != nil {
+		return nil, extendErr
+	}
+
+	return envSet.Env(environment.NewExpressions)
+}
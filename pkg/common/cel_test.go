@@ -0,0 +1,99 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCELParse(t *testing.T) {
+	// given
+	expression := `object.metadata.name.startsWith("my-") && eventType == "update"`
+
+	// when
+	program := CELParse(expression)
+
+	// then
+	assert.NotNil(t, program)
+	assert.Panics(t, func() { CELParse(`object.metadata.name`) })
+	assert.Panics(t, func() { CELParse(`unknown == "value"`) })
+	assert.Panics(t, func() { CELParse(`object.metadata.name ==`) })
+}
+
+func TestCELEvaluate(t *testing.T) {
+	// given
+	var (
+		program = CELParse(`object.data.replicas > oldObject.data.replicas && ` +
+			`quantity(object.data.memory).isGreaterThan(quantity("1Gi"))`)
+		variables = map[string]any{
+			CELVariableObject: map[string]any{
+				"data": map[string]any{"replicas": int64(3), "memory": "2Gi"},
+			},
+			CELVariableOldObject: map[string]any{
+				"data": map[string]any{"replicas": int64(2)},
+			},
+			CELVariableEventType: "update",
+		}
+	)
+
+	// when
+	matched, evaluateErr := CELEvaluate(context.Background(), program, variables)
+
+	// then
+	assert.Nil(t, evaluateErr)
+	assert.True(t, matched)
+}
+
+func TestCELEvaluate_CostLimit(t *testing.T) {
+	// given
+	var (
+		program = CELParse(`object.items.all(x, object.items.all(y, object.items.all(z, x + y + z >= 0)))`)
+		items   = make([]any, 200)
+	)
+
+	for index := range items {
+		items[index] = int64(index)
+	}
+
+	// when
+	matched, evaluateErr := CELEvaluate(context.Background(), program, map[string]any{
+		CELVariableObject:    map[string]any{"items": items},
+		CELVariableOldObject: nil,
+		CELVariableEventType: "create",
+	})
+
+	// then
+	assert.ErrorContains(t, evaluateErr, "cost limit exceeded")
+	assert.False(t, matched)
+}
+
+func TestCELEvaluate_OldObjectMissing(t *testing.T) {
+	for _, test := range []struct {
+		expression string
+		matched    bool
+		missing    bool
+		failed     bool
+	}{
+		{expression: `oldObject == null || object.data.key != oldObject.data.key`, matched: true},
+		{expression: `object.data.key != oldObject.data.key`, missing: true, failed: true},
+		{expression: `oldObject["data"].key.size() > 0`, missing: true, failed: true},
+		{expression: `object.data.missing != oldObject.data.key`, failed: true},
+		{expression: `int(object.data.key) > 0 && oldObject == null`, failed: true},
+	} {
+		t.Run(test.expression, func(t *testing.T) {
+			// when
+			matched, evaluateErr := CELEvaluate(context.Background(), CELParse(test.expression), map[string]any{
+				CELVariableObject:    map[string]any{"data": map[string]any{"key": "value"}},
+				CELVariableOldObject: nil,
+				CELVariableEventType: "create",
+			})
+
+			// then
+			assert.Equal(t, test.matched, matched)
+			assert.Equal(t, test.missing, errors.Is(evaluateErr, ErrCELOldObjectMissing))
+			assert.Equal(t, test.failed, evaluateErr != nil)
+		})
+	}
+}
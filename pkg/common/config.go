@@ -7,9 +7,11 @@ import (
 )
 
 type Config struct {
-	EnableLeaderElection bool          `env:"ENABLE_LEADER_ELECTION" envDefault:"false"`
-	SyncPeriod           time.Duration `env:"SYNC_PERIOD" envDefault:"24h"`
-	WatcherRefreshPeriod time.Duration `env:"WATCHER_REFRESH_PERIOD" envDefault:"15s"`
+	EnableLeaderElection  bool          `env:"ENABLE_LEADER_ELECTION" envDefault:"false"`
+	SyncPeriod            time.Duration `env:"SYNC_PERIOD" envDefault:"24h"`
+	WatcherRefreshPeriod  time.Duration `env:"WATCHER_REFRESH_PERIOD" envDefault:"15s"`
+	SourceDiscoveryPeriod time.Duration `env:"SOURCE_DISCOVERY_PERIOD" envDefault:"15s"`
+	LookupKinds           []string      `env:"LOOKUP_KINDS" envDefault:"ConfigMap,Namespace,Deployment.apps"`
 }
 
 func NewConfig() *Config {
@@ -0,0 +1,162 @@
+package common
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	FieldPathWildcard = "*"
+)
+
+func FieldPathParse(path string) []string {
+	var (
+		segments []string
+		current  strings.Builder
+		flush    = func() {
+			if current.Len() > 0 {
+				segments = append(segments, current.String())
+				current.Reset()
+			}
+		}
+	)
+
+	path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+
+	for index := 0; index < len(path); index++ {
+		switch path[index] {
+		case '.':
+			flush()
+		case '[':
+			flush()
+
+			end := strings.IndexByte(path[index:], ']')
+			if end < 0 {
+				end = len(path) - index
+			}
+
+			segments = append(segments, strings.Trim(path[index+1:index+end], `'"`))
+			index += end
+		default:
+			current.WriteByte(path[index])
+		}
+	}
+
+	flush()
+
+	return segments
+}
+
+func FieldPathGet(value any, segments []string) []any {
+	if len(segments) == 0 {
+		return []any{value}
+	}
+
+	var values []any
+
+	for _, child := range fieldPathChildren(value, segments[0]) {
+		values = append(values, FieldPathGet(child, segments[1:])...)
+	}
+
+	return values
+}
+
+// FieldPathRemove removes the fields and the list items of the path from the value and returns it, which is a new
+// list when the items of the list at the top level are removed.
+func FieldPathRemove(value any, segments []string) any {
+	if len(segments) == 0 {
+		return value
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		fieldPathRemoveFromMap(typed, segments)
+	case []any:
+		return fieldPathRemoveFromList(typed, segments)
+	}
+
+	return value
+}
+
+func FieldPathsEqual(a, b map[string]any, paths [][]string) bool {
+	for _, segments := range paths {
+		if !reflect.DeepEqual(FieldPathGet(a, segments), FieldPathGet(b, segments)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func fieldPathChildren(value any, segment string) []any {
+	switch typed := value.(type) {
+	case map[string]any:
+		if segment != FieldPathWildcard {
+			if child, found := typed[segment]; found {
+				return []any{child}
+			}
+
+			return nil
+		}
+
+		children := make([]any, 0, len(typed))
+		for _, key := range slices.Sorted(maps.Keys(typed)) {
+			children = append(children, typed[key])
+		}
+
+		return children
+	case []any:
+		if segment == FieldPathWildcard {
+			return typed
+		}
+
+		if index, parseErr := strconv.Atoi(segment); parseErr == nil && index >= 0 && index < len(typed) {
+			return []any{typed[index]}
+		}
+	}
+
+	return nil
+}
+
+func fieldPathRemoveFromMap(object map[string]any, segments []string) {
+	if len(segments) == 1 {
+		if segments[0] == FieldPathWildcard {
+			clear(object)
+		} else {
+			delete(object, segments[0])
+		}
+
+		return
+	}
+
+	for key, child := range object {
+		if segments[0] == FieldPathWildcard || segments[0] == key {
+			object[key] = FieldPathRemove(child, segments[1:])
+		}
+	}
+}
+
+func fieldPathRemoveFromList(list []any, segments []string) []any {
+	if len(segments) == 1 && segments[0] == FieldPathWildcard {
+		return []any{}
+	}
+
+	if len(segments) == 1 {
+		if index, parseErr := strconv.Atoi(segments[0]); parseErr == nil && index >= 0 && index < len(list) {
+			return slices.Delete(slices.Clone(list), index, index+1)
+		}
+
+		return list
+	}
+
+	for index, child := range list {
+		if segments[0] == FieldPathWildcard || segments[0] == strconv.Itoa(index) {
+			list[index] = FieldPathRemove(child, segments[1:])
+		}
+	}
+
+	return list
+}
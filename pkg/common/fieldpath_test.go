@@ -0,0 +1,110 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldPathParse(t *testing.T) {
+	// given
+	paths := []string{
+		"status.phase",
+		".spec.replicas",
+		"$.status.conditions[*].lastHeartbeatTime",
+		"spec.containers[0].image",
+		"metadata.labels['app.kubernetes.io/name']",
+	}
+
+	// when
+	var segments [][]string
+	for _, path := range paths {
+		segments = append(segments, FieldPathParse(path))
+	}
+
+	// then
+	assert.Equal(t, [][]string{
+		{"status", "phase"},
+		{"spec", "replicas"},
+		{"status", "conditions", "*", "lastHeartbeatTime"},
+		{"spec", "containers", "0", "image"},
+		{"metadata", "labels", "app.kubernetes.io/name"},
+	}, segments)
+}
+
+func TestFieldPathGet(t *testing.T) {
+	// given
+	object := map[string]any{
+		"spec": map[string]any{
+			"containers": []any{
+				map[string]any{"name": "web", "image": "web:1"},
+				map[string]any{"name": "sidecar", "image": "proxy:2"},
+			},
+		},
+	}
+
+	// when
+	images := FieldPathGet(object, FieldPathParse("spec.containers[*].image"))
+	first := FieldPathGet(object, FieldPathParse("spec.containers[0].name"))
+	missing := FieldPathGet(object, FieldPathParse("spec.containers[5].name"))
+
+	// then
+	assert.Equal(t, []any{"web:1", "proxy:2"}, images)
+	assert.Equal(t, []any{"web"}, first)
+	assert.Empty(t, missing)
+}
+
+func TestFieldPathRemove(t *testing.T) {
+	// given
+	object := map[string]any{
+		"metadata": map[string]any{"name": "node", "managedFields": []any{}},
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{"type": "Ready", "lastHeartbeatTime": "10:00"},
+				map[string]any{"type": "DiskPressure", "lastHeartbeatTime": "10:01"},
+				map[string]any{"type": "PIDPressure"},
+			},
+			"addresses": []any{"10.0.0.1", "10.0.0.2"},
+		},
+	}
+	list := []any{"first", "second"}
+
+	// when
+	FieldPathRemove(object, FieldPathParse("metadata.managedFields"))
+	FieldPathRemove(object, FieldPathParse("status.conditions[*].lastHeartbeatTime"))
+	FieldPathRemove(object, FieldPathParse("status.conditions[2]"))
+	FieldPathRemove(object, FieldPathParse("status.addresses[*]"))
+	removed := FieldPathRemove(list, FieldPathParse("[0]"))
+
+	// then
+	assert.Equal(t, map[string]any{
+		"metadata": map[string]any{"name": "node"},
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{"type": "Ready"},
+				map[string]any{"type": "DiskPressure"},
+			},
+			"addresses": []any{},
+		},
+	}, object)
+	assert.Equal(t, []any{"second"}, removed)
+	assert.Equal(t, []any{"first", "second"}, list)
+}
+
+func TestFieldPathsEqual(t *testing.T) {
+	// given
+	var (
+		a     = map[string]any{"spec": map[string]any{"replicas": int64(1)}, "status": map[string]any{"phase": "A"}}
+		b     = map[string]any{"spec": map[string]any{"replicas": int64(1)}, "status": map[string]any{"phase": "B"}}
+		spec  = [][]string{FieldPathParse("spec.replicas")}
+		phase = [][]string{FieldPathParse("spec.replicas"), FieldPathParse("status.phase")}
+	)
+
+	// when
+	specEqual := FieldPathsEqual(a, b, spec)
+	phaseEqual := FieldPathsEqual(a, b, phase)
+
+	// then
+	assert.True(t, specEqual)
+	assert.False(t, phaseEqual)
+}
@@ -0,0 +1,208 @@
+package common
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	filePermission      = 0o644
+	directoryPermission = 0o755
+	compressedExtension = ".gz"
+)
+
+var (
+	rotatingFiles     = map[string]*RotatingFile{}
+	rotatingFilesLock sync.Mutex
+)
+
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	compress   bool
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+func NewRotatingFile(path string, maxSize int64, maxBackups int, compress bool) *RotatingFile {
+	return &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		compress:   compress,
+	}
+}
+
+// SharedRotatingFile returns the rotating file of the cleaned path, creating it the first time, so the writers of the
+// same path share one file and rotate it once. The file is rotated with the options it was returned with last.
+func SharedRotatingFile(path string, maxSize int64, maxBackups int, compress bool) *RotatingFile {
+	rotatingFilesLock.Lock()
+	defer rotatingFilesLock.Unlock()
+
+	path = filepath.Clean(path)
+
+	file := rotatingFiles[path]
+	if file == nil {
+		file = NewRotatingFile(path, maxSize, maxBackups, compress)
+		rotatingFiles[path] = file
+
+		return file
+	}
+
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+
+	file.maxSize, file.maxBackups, file.compress = maxSize, maxBackups, compress
+
+	return file
+}
+
+func (f *RotatingFile) Write(data []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		if openErr := f.open(); openErr != nil {
+			return 0, openErr
+		}
+	}
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(data)) > f.maxSize {
+		if rotateErr := f.rotate(); rotateErr != nil {
+			return 0, rotateErr
+		}
+	}
+
+	written, writeErr := f.file.Write(data)
+	f.size += int64(written)
+
+	return written, writeErr
+}
+
+func (f *RotatingFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	closeErr := f.file.Close()
+	f.file = nil
+
+	return closeErr
+}
+
+func (f *RotatingFile) open() error {
+	if mkdirErr := os.MkdirAll(filepath.Dir(f.path), directoryPermission); mkdirErr != nil {
+		return mkdirErr
+	}
+
+	file, openErr := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, filePermission)
+	if openErr != nil {
+		return openErr
+	}
+
+	info, statErr := file.Stat()
+	if statErr != nil {
+		_ = file.Close()
+
+		return statErr
+	}
+
+	f.file = file
+	f.size = info.Size()
+
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	if closeErr := f.file.Close(); closeErr != nil {
+		return closeErr
+	}
+
+	f.file = nil
+
+	if f.maxBackups <= 0 {
+		if removeErr := os.Remove(f.path); removeErr != nil {
+			return removeErr
+		}
+
+		return f.open()
+	}
+
+	if removeErr := os.Remove(f.backupPath(f.maxBackups)); removeErr != nil && !errors.Is(removeErr, fs.ErrNotExist) {
+		return removeErr
+	}
+
+	for index := f.maxBackups - 1; index > 0; index-- {
+		renameErr := os.Rename(f.backupPath(index), f.backupPath(index+1))
+		if renameErr != nil && !errors.Is(renameErr, fs.ErrNotExist) {
+			return renameErr
+		}
+	}
+
+	if f.compress {
+		if compressErr := compressFile(f.path, f.backupPath(1)); compressErr != nil {
+			return compressErr
+		}
+	} else if renameErr := os.Rename(f.path, f.backupPath(1)); renameErr != nil {
+		return renameErr
+	}
+
+	return f.open()
+}
+
+func (f *RotatingFile) backupPath(index int) string {
+	if f.compress {
+		return fmt.Sprintf("%s.%d%s", f.path, index, compressedExtension)
+	}
+
+	return fmt.Sprintf("%s.%d", f.path, index)
+}
+
+func compressFile(source, destination string) error {
+	sourceFile, openErr := os.Open(filepath.Clean(source))
+	if openErr != nil {
+		return openErr
+	}
+
+	defer func() {
+		_ = sourceFile.Close()
+	}()
+
+	destinationFile, createErr := os.OpenFile(filepath.Clean(destination),
+		os.O_CREATE|os.O_TRUNC|os.O_WRONLY, filePermission)
+	if createErr != nil {
+		return createErr
+	}
+
+	gzipWriter := gzip.NewWriter(destinationFile)
+
+	if _, copyErr := io.Copy(gzipWriter, sourceFile); copyErr != nil {
+		_ = destinationFile.Close()
+
+		return copyErr
+	}
+
+	if closeErr := gzipWriter.Close(); closeErr != nil {
+		_ = destinationFile.Close()
+
+		return closeErr
+	}
+
+	if closeErr := destinationFile.Close(); closeErr != nil {
+		return closeErr
+	}
+
+	return os.Remove(source)
+}
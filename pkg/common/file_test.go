@@ -0,0 +1,111 @@
+package common
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFile_Write(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "nested", "objects.jsonl")
+	file := NewRotatingFile(path, 0, 1, false)
+
+	// when
+	_, firstWriteErr := file.Write([]byte("first\n"))
+	_, secondWriteErr := file.Write([]byte("second\n"))
+	closeErr := file.Close()
+
+	// then
+	assert.Nil(t, firstWriteErr)
+	assert.Nil(t, secondWriteErr)
+	assert.Nil(t, closeErr)
+	content, readErr := os.ReadFile(path)
+	assert.Nil(t, readErr)
+	assert.Equal(t, "first\nsecond\n", string(content))
+}
+
+func TestSharedRotatingFile(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "objects.jsonl")
+
+	// when
+	first := SharedRotatingFile(path, 10, 1, false)
+	second := SharedRotatingFile(path+"/", 20, 2, true)
+	other := SharedRotatingFile(path+".other", 10, 1, false)
+
+	// then
+	assert.Same(t, first, second)
+	assert.NotSame(t, first, other)
+	assert.Equal(t, int64(20), first.maxSize)
+	assert.Equal(t, 2, first.maxBackups)
+	assert.True(t, first.compress)
+}
+
+func TestRotatingFile_Rotate(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "objects.jsonl")
+	file := NewRotatingFile(path, 10, 2, false)
+
+	// when
+	for _, line := range []string{"line-1\n", "line-2\n", "line-3\n", "line-4\n"} {
+		_, writeErr := file.Write([]byte(line))
+		assert.Nil(t, writeErr)
+	}
+
+	_ = file.Close()
+
+	// then
+	current, _ := os.ReadFile(path)
+	firstBackup, _ := os.ReadFile(path + ".1")
+	secondBackup, _ := os.ReadFile(path + ".2")
+	assert.Equal(t, "line-4\n", string(current))
+	assert.Equal(t, "line-3\n", string(firstBackup))
+	assert.Equal(t, "line-2\n", string(secondBackup))
+	assert.NoFileExists(t, path+".3")
+}
+
+func TestRotatingFile_RotateCompressed(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "objects.jsonl")
+	file := NewRotatingFile(path, 10, 1, true)
+
+	// when
+	_, _ = file.Write([]byte("line-1\n"))
+	_, _ = file.Write([]byte("line-2\n"))
+	_ = file.Close()
+
+	// then
+	assert.NoFileExists(t, path+".1")
+	compressed, openErr := os.Open(path + ".1.gz")
+	assert.Nil(t, openErr)
+
+	defer func() {
+		_ = compressed.Close()
+	}()
+
+	reader, readerErr := gzip.NewReader(compressed)
+	assert.Nil(t, readerErr)
+	content, _ := io.ReadAll(reader)
+	assert.Equal(t, "line-1\n", string(content))
+}
+
+func TestRotatingFile_RotateWithoutBackups(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "objects.jsonl")
+	file := NewRotatingFile(path, 10, 0, false)
+
+	// when
+	_, _ = file.Write([]byte("line-1\n"))
+	_, _ = file.Write([]byte("line-2\n"))
+	_ = file.Close()
+
+	// then
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "line-2\n", string(content))
+	assert.NoFileExists(t, path+".1")
+}
@@ -0,0 +1,59 @@
+package common
+
+import (
+	"encoding/json"
+
+	mergepatch "github.com/evanphx/json-patch/v5"
+	"gomodules.xyz/jsonpatch/v2"
+)
+
+const (
+	EmptyJSONPatch  = "[]"
+	EmptyMergePatch = "{}"
+)
+
+func JSONPatch(original, modified any) (string, error) {
+	originalJSON, modifiedJSON, marshalErr := marshalPair(original, modified)
+	if marshalErr != nil {
+		return "", marshalErr
+	}
+
+	operations, patchErr := jsonpatch.CreatePatch(originalJSON, modifiedJSON)
+	if patchErr != nil {
+		return "", patchErr
+	}
+
+	if len(operations) == 0 {
+		return EmptyJSONPatch, nil
+	}
+
+	patch, marshalErr := json.Marshal(operations)
+
+	return string(patch), marshalErr
+}
+
+func MergePatch(original, modified any) (string, error) {
+	originalJSON, modifiedJSON, marshalErr := marshalPair(original, modified)
+	if marshalErr != nil {
+		return "", marshalErr
+	}
+
+	patch, patchErr := mergepatch.CreateMergePatch(originalJSON, modifiedJSON)
+
+	return string(patch), patchErr
+}
+
+func marshalPair(original, modified any) ([]byte, []byte, error) {
+	if original == nil {
+		original = map[string]any{}
+	}
+
+	originalJSON, originalErr := json.Marshal(original)
+	if originalErr != nil {
+		return nil, nil, originalErr
+	}
+
+	modifiedJSON, modifiedErr := json.Marshal(modified)
+
+	return originalJSON, modifiedJSON, modifiedErr
+}
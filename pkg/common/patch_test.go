@@ -0,0 +1,67 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONPatch(t *testing.T) {
+	// given
+	var (
+		original = map[string]any{"spec": map[string]any{"replicas": 1, "paused": true}}
+		modified = map[string]any{"spec": map[string]any{"replicas": 3}}
+	)
+
+	// when
+	patch, patchErr := JSONPatch(original, modified)
+	emptyPatch, _ := JSONPatch(original, original)
+	createPatch, _ := JSONPatch(nil, modified)
+
+	// then
+	var operations []map[string]any
+	assert.Nil(t, patchErr)
+	assert.Nil(t, json.Unmarshal([]byte(patch), &operations))
+	assert.ElementsMatch(t, []map[string]any{
+		{"op": "remove", "path": "/spec/paused"},
+		{"op": "replace", "path": "/spec/replicas", "value": float64(3)},
+	}, operations)
+	assert.Equal(t, EmptyJSONPatch, emptyPatch)
+	assert.JSONEq(t, `[{"op":"add","path":"/spec","value":{"replicas":3}}]`, createPatch)
+}
+
+func TestMergePatch(t *testing.T) {
+	// given
+	var (
+		original = map[string]any{"spec": map[string]any{"replicas": 1, "paused": true}}
+		modified = map[string]any{"spec": map[string]any{"replicas": 3}}
+	)
+
+	// when
+	patch, patchErr := MergePatch(original, modified)
+	emptyPatch, _ := MergePatch(original, original)
+
+	// then
+	assert.Nil(t, patchErr)
+	assert.JSONEq(t, `{"spec":{"paused":null,"replicas":3}}`, patch)
+	assert.Equal(t, EmptyMergePatch, emptyPatch)
+}
+
+func TestTemplateParse_PatchFunctions(t *testing.T) {
+	// given
+	var (
+		template = TemplateParse(`{{ jsonPatch .old .new }} {{ mergePatch .old .new }}`)
+		data     = map[string]any{
+			"old": map[string]any{"phase": "Pending"},
+			"new": map[string]any{"phase": "Running"},
+		}
+	)
+
+	// when
+	result, executeErr := TemplateExecute(template, data)
+
+	// then
+	assert.Nil(t, executeErr)
+	assert.Equal(t, `[{"op":"replace","path":"/phase","value":"Running"}] {"phase":"Running"}`, string(result))
+}
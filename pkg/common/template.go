@@ -0,0 +1,79 @@
+package common
+
+import (
+	"slices"
+	"text/template"
+	"text/template/parse"
+)
+
+// TemplateReadsOnly reports whether the template reads only the given top-level keys of its data. It's conservative,
+// so the templates passing the data itself like {{ toJson . }} are considered to read everything.
+func TemplateReadsOnly(tpl *template.Template, keys ...string) bool {
+	for _, associated := range tpl.Templates() {
+		if associated.Tree != nil && !nodeReadsOnly(associated.Root, keys) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func nodeReadsOnly(node parse.Node, keys []string) bool {
+	switch node := node.(type) {
+	case *parse.DotNode:
+		return false
+	case *parse.FieldNode:
+		return slices.Contains(keys, node.Ident[0])
+	case *parse.VariableNode:
+		return node.Ident[0] != "$" || (len(node.Ident) > 1 && slices.Contains(keys, node.Ident[1]))
+	}
+
+	for _, child := range childNodes(node) {
+		if !nodeReadsOnly(child, keys) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func childNodes(node parse.Node) []parse.Node {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		return node.Nodes
+	case *parse.ChainNode:
+		return []parse.Node{node.Node}
+	case *parse.ActionNode:
+		return []parse.Node{node.Pipe}
+	case *parse.CommandNode:
+		return node.Args
+	case *parse.PipeNode:
+		commands := make([]parse.Node, 0, len(node.Cmds))
+		for _, command := range node.Cmds {
+			commands = append(commands, command)
+		}
+
+		return commands
+	case *parse.TemplateNode:
+		if node.Pipe != nil {
+			return []parse.Node{node.Pipe}
+		}
+	case *parse.IfNode:
+		return branchNodes(&node.BranchNode)
+	case *parse.RangeNode:
+		return branchNodes(&node.BranchNode)
+	case *parse.WithNode:
+		return branchNodes(&node.BranchNode)
+	}
+
+	return nil
+}
+
+func branchNodes(branch *parse.BranchNode) []parse.Node {
+	nodes := []parse.Node{branch.Pipe, branch.List}
+	if branch.ElseList != nil {
+		nodes = append(nodes, branch.ElseList)
+	}
+
+	return nodes
+}
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateReadsOnly(t *testing.T) {
+	// given
+	keys := []string{"kind", "metadata"}
+
+	// when
+	metadata := TemplateReadsOnly(TemplateParse(`{{ .kind }}/{{ .metadata.name | upper }}`), keys...)
+	branches := TemplateReadsOnly(TemplateParse(
+		`{{ if eq .kind "Secret" }}{{ range $k, $v := .metadata.labels }}{{ $k }}={{ $v }}{{ end }}{{ end }}`), keys...)
+	root := TemplateReadsOnly(TemplateParse(`{{ $.metadata.name }}`), keys...)
+	data := TemplateReadsOnly(TemplateParse(`{{ .metadata.name }}{{ index .data "key" }}`), keys...)
+	dot := TemplateReadsOnly(TemplateParse(`{{ toJson . }}`), keys...)
+	elseData := TemplateReadsOnly(TemplateParse(`{{ with .metadata }}{{ else }}{{ .spec }}{{ end }}`), keys...)
+	empty := TemplateReadsOnly(TemplateParse(""), keys...)
+
+	// then
+	assert.True(t, metadata)
+	assert.True(t, branches)
+	assert.True(t, root)
+	assert.False(t, data)
+	assert.False(t, dot)
+	assert.False(t, elseData)
+	assert.True(t, empty)
+}
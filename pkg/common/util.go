@@ -2,30 +2,243 @@ package common
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig/v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
 )
 
 const (
 	RequiredHeaderPartCount = 2
 )
 
+var (
+	ErrLookupUnavailable = errors.New("lookup isn't available")
+	ErrUnsupportedValue  = errors.New("unsupported value")
+	ErrPanicked          = errors.New("panicked")
+)
+
+// Recover calls the function, returning what it panics with, like the errors of Must, as an error.
+func Recover(function func()) (err error) {
+	defer func() {
+		recovered := recover()
+		if recoveredErr, isErr := recovered.(error); isErr {
+			err = fmt.Errorf("%w: %w", ErrPanicked, recoveredErr)
+		} else if recovered != nil {
+			err = fmt.Errorf("%w: %v", ErrPanicked, recovered)
+		}
+	}()
+
+	function()
+
+	return nil
+}
+
 func TemplateParse(str string) *template.Template {
-	return template.Must(template.New("self").Funcs(sprig.TxtFuncMap()).Parse(str))
+	return template.Must(template.New("self").Funcs(TemplateFuncMap()).Parse(str))
+}
+
+func TemplateFuncMap() template.FuncMap {
+	funcMap := sprig.TxtFuncMap()
+	funcMap["jsonPatch"] = JSONPatch
+	funcMap["mergePatch"] = MergePatch
+	funcMap["lookup"] = lookupUnavailable
+	funcMap["toJson"] = ToJSON
+	funcMap["toYaml"] = ToYAML
+	funcMap["jsonpath"] = JSONPath
+	funcMap["b64decSecret"] = B64DecSecret
+	funcMap["quantity"] = Quantity
+	funcMap["humanDuration"] = HumanDuration
+	funcMap["condition"] = Condition
+	funcMap["ownerOf"] = OwnerOf
+
+	return funcMap
+}
+
+// lookupUnavailable is replaced with the lookup of the watcher when the template is executed for its objects.
+func lookupUnavailable(_, _, _, _ string) (map[string]any, error) {
+	return nil, ErrLookupUnavailable
 }
 
 func TemplateExecuteForObject(template *template.Template, obj *unstructured.Unstructured) ([]byte, error) {
+	return TemplateExecute(template, obj.Object)
+}
+
+func TemplateExecute(template *template.Template, data any) ([]byte, error) {
 	var buffer bytes.Buffer
-	if executeErr := template.Execute(&buffer, obj.Object); executeErr != nil {
+	if executeErr := template.Execute(&buffer, data); executeErr != nil {
 		return nil, executeErr
 	}
 
 	return buffer.Bytes(), nil
 }
 
+// TemplateWithFuncs returns a copy of the template with the given functions replacing the ones it's parsed with, which
+// can be executed concurrently like the template.
+func TemplateWithFuncs(template *template.Template, funcs template.FuncMap) (*template.Template, error) {
+	clone, cloneErr := template.Clone()
+	if cloneErr != nil {
+		return nil, cloneErr
+	}
+
+	return clone.Funcs(funcs), nil
+}
+
+// ToJSON returns the JSON of the value, escaping the quotes, the control characters and the HTML characters in its
+// strings so it can be embedded in any JSON body. Unlike the toJson of sprig, the marshal errors aren't swallowed.
+func ToJSON(value any) (string, error) {
+	content, marshalErr := json.Marshal(value)
+	if marshalErr != nil {
+		return "", marshalErr
+	}
+
+	return string(content), nil
+}
+
+// ToYAML returns the YAML of the value without its trailing newline.
+func ToYAML(value any) (string, error) {
+	content, marshalErr := yaml.Marshal(value)
+	if marshalErr != nil {
+		return "", marshalErr
+	}
+
+	return strings.TrimSuffix(string(content), "\n"), nil
+}
+
+// JSONPath returns the result of the JSONPath template like kubectl, where the braces are optional for a single
+// expression. The missing keys result in an empty string.
+func JSONPath(path string, data any) (string, error) {
+	if !strings.Contains(path, "{") {
+		path = "{" + path + "}"
+	}
+
+	parser := jsonpath.New("jsonpath").AllowMissingKeys(true)
+	if parseErr := parser.Parse(path); parseErr != nil {
+		return "", parseErr
+	}
+
+	var buffer bytes.Buffer
+	if executeErr := parser.Execute(&buffer, data); executeErr != nil {
+		return "", executeErr
+	}
+
+	return buffer.String(), nil
+}
+
+// B64DecSecret returns the decoded value of the key in the data of the Secret, or an empty string when it's missing.
+func B64DecSecret(key string, secret map[string]any) (string, error) {
+	encoded, _, _ := unstructured.NestedString(secret, "data", key)
+
+	decoded, decodeErr := base64.StdEncoding.DecodeString(encoded)
+	if decodeErr != nil {
+		return "", decodeErr
+	}
+
+	return string(decoded), nil
+}
+
+// Quantity parses the value like 500m or 1Gi, so that it can be formatted or converted with .Value or .MilliValue.
+func Quantity(value any) (*resource.Quantity, error) {
+	switch value := value.(type) {
+	case string:
+		quantity, parseErr := resource.ParseQuantity(value)
+
+		return &quantity, parseErr
+	case int:
+		return resource.NewQuantity(int64(value), resource.DecimalSI), nil
+	case int64:
+		return resource.NewQuantity(value, resource.DecimalSI), nil
+	case float64:
+		quantity, parseErr := resource.ParseQuantity(strconv.FormatFloat(value, 'f', -1, 64))
+
+		return &quantity, parseErr
+	case resource.Quantity:
+		return &value, nil
+	}
+
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedValue, value)
+}
+
+// HumanDuration returns the time since the timestamp like kubectl, as in 5m or 3d4h. The empty timestamps result in an
+// empty string.
+func HumanDuration(timestamp any) (string, error) {
+	var since time.Time
+
+	switch timestamp := timestamp.(type) {
+	case nil:
+		return "", nil
+	case string:
+		if timestamp == "" {
+			return "", nil
+		}
+
+		parsed, parseErr := time.Parse(time.RFC3339, timestamp)
+		if parseErr != nil {
+			return "", parseErr
+		}
+
+		since = parsed
+	case time.Time:
+		since = timestamp
+	case metav1.Time:
+		since = timestamp.Time
+	default:
+		return "", fmt.Errorf("%w: %T", ErrUnsupportedValue, timestamp)
+	}
+
+	return duration.HumanDuration(time.Since(since)), nil
+}
+
+// Condition returns the condition of the type in the status of the object, or nil when it's missing.
+func Condition(conditionType string, obj map[string]any) map[string]any {
+	conditions, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+
+	for _, condition := range conditions {
+		if conditionMap, isMap := condition.(map[string]any); isMap && conditionMap["type"] == conditionType {
+			return conditionMap
+		}
+	}
+
+	return nil
+}
+
+// OwnerOf returns the controller owner reference of the object, its first owner reference when none of them is the
+// controller, or nil when it has none.
+func OwnerOf(obj map[string]any) map[string]any {
+	references, _, _ := unstructured.NestedSlice(obj, "metadata", "ownerReferences")
+
+	var owner map[string]any
+
+	for _, reference := range references {
+		referenceMap, isMap := reference.(map[string]any)
+		if !isMap {
+			continue
+		}
+
+		if controller, _ := referenceMap["controller"].(bool); controller {
+			return referenceMap
+		}
+
+		if owner == nil {
+			owner = referenceMap
+		}
+	}
+
+	return owner
+}
+
 func MapContains(a, b map[string]string) bool {
 	for key, val := range b {
 		valA, contains := a[key]
@@ -3,8 +3,10 @@ package common
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
@@ -36,6 +38,214 @@ func TestTemplateExecuteForObject(t *testing.T) {
 	assert.Equal(t, "test", string(result))
 }
 
+func TestTemplateExecute(t *testing.T) {
+	// given
+	template := TemplateParse("[{{ join \",\" .items }}]")
+	data := map[string]any{
+		"items": []string{"1", "2"},
+	}
+
+	// when
+	result, err := TemplateExecute(template, data)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,2]", string(result))
+}
+
+func TestTemplateWithFuncs(t *testing.T) {
+	// given
+	template := TemplateParse(`{{ (lookup "v1" "ConfigMap" "default" .name).data.team }}`)
+	lookup := func(apiVersion, kind, namespace, name string) (map[string]any, error) {
+		return map[string]any{"data": map[string]any{"team": apiVersion + "/" + kind + "/" + namespace + "/" + name}}, nil
+	}
+	data := map[string]any{"name": "contacts"}
+
+	// when
+	withLookup, err := TemplateWithFuncs(template, map[string]any{"lookup": lookup})
+	result, executeErr := TemplateExecute(withLookup, data)
+	_, unavailableErr := TemplateExecute(template, data)
+
+	// then
+	assert.Nil(t, err)
+	assert.Nil(t, executeErr)
+	assert.Equal(t, "v1/ConfigMap/default/contacts", string(result))
+	assert.ErrorIs(t, unavailableErr, ErrLookupUnavailable)
+}
+
+func TestToJSON(t *testing.T) {
+	// given
+	template := TemplateParse(`{"name": {{ toJson .metadata.name }}, "labels": {{ toJson .metadata.labels }}}`)
+	data := map[string]any{"metadata": map[string]any{
+		"name":   "my-\"app\"\n<script>",
+		"labels": map[string]any{"team": "payments"},
+	}}
+
+	// when
+	result, err := TemplateExecute(template, data)
+	_, marshalErr := ToJSON(make(chan int))
+
+	// then
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"name": "my-\"app\"\n<script>", "labels": {"team": "payments"}}`, string(result))
+	assert.NotContains(t, string(result), "<script>")
+	assert.Error(t, marshalErr)
+}
+
+func TestToYAML(t *testing.T) {
+	// given
+	data := map[string]any{"metadata": map[string]any{"name": "my-app", "labels": map[string]any{"team": "payments"}}}
+
+	// when
+	result, err := ToYAML(data["metadata"])
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, "labels:\n  team: payments\nname: my-app", result)
+}
+
+func TestJSONPath(t *testing.T) {
+	// given
+	data := map[string]any{
+		"metadata": map[string]any{"name": "my-pod"},
+		"spec": map[string]any{"containers": []any{
+			map[string]any{"name": "app", "image": "app:1.0"},
+			map[string]any{"name": "sidecar", "image": "proxy:2.0"},
+		}},
+	}
+
+	// when
+	name, nameErr := JSONPath(".metadata.name", data)
+	images, imagesErr := JSONPath("{.spec.containers[*].image}", data)
+	missing, missingErr := JSONPath(".status.phase", data)
+	_, parseErr := JSONPath("{.spec.containers[", data)
+
+	// then
+	assert.Nil(t, nameErr)
+	assert.Equal(t, "my-pod", name)
+	assert.Nil(t, imagesErr)
+	assert.Equal(t, "app:1.0 proxy:2.0", images)
+	assert.Nil(t, missingErr)
+	assert.Empty(t, missing)
+	assert.Error(t, parseErr)
+}
+
+func TestB64DecSecret(t *testing.T) {
+	// given
+	template := TemplateParse(`{{ b64decSecret "token" . }}`)
+	secret := map[string]any{"data": map[string]any{"token": "c2VjcmV0Ig==", "invalid": "%%%"}}
+
+	// when
+	result, err := TemplateExecute(template, secret)
+	missing, missingErr := B64DecSecret("missing", secret)
+	_, decodeErr := B64DecSecret("invalid", secret)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, `secret"`, string(result))
+	assert.Nil(t, missingErr)
+	assert.Empty(t, missing)
+	assert.Error(t, decodeErr)
+}
+
+func TestQuantity(t *testing.T) {
+	for name, testCase := range map[string]struct {
+		value       any
+		template    string
+		result      string
+		unsupported bool
+	}{
+		"format":       {value: "1500m", template: "{{ quantity .value }}", result: "1500m"},
+		"bytes":        {value: "1Gi", template: "{{ (quantity .value).Value }}", result: "1073741824"},
+		"milli value":  {value: "0.5", template: "{{ (quantity .value).MilliValue }}", result: "500"},
+		"integer":      {value: int64(3), template: "{{ quantity .value }}", result: "3"},
+		"float":        {value: 2.5, template: "{{ (quantity .value).MilliValue }}", result: "2500"},
+		"invalid":      {value: "lots", template: "{{ quantity .value }}", unsupported: true},
+		"unsupported":  {value: true, template: "{{ quantity .value }}", unsupported: true},
+		"missing":      {template: "{{ quantity .value }}", unsupported: true},
+		"compared":     {value: "2Gi", template: `{{ gt (quantity .value).Value (quantity "1Gi").Value }}`, result: "true"},
+		"milli format": {value: "100m", template: "{{ (quantity .value).AsDec }}", result: "0.100"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// when
+			result, err := TemplateExecute(TemplateParse(testCase.template), map[string]any{"value": testCase.value})
+
+			// then
+			if testCase.unsupported {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.result, string(result))
+		})
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	// given
+	created := time.Now().Add(-(3*time.Hour + 10*time.Minute))
+
+	// when
+	fromString, stringErr := HumanDuration(created.UTC().Format(time.RFC3339))
+	fromTime, timeErr := HumanDuration(metav1.NewTime(created))
+	empty, emptyErr := HumanDuration("")
+	_, parseErr := HumanDuration("yesterday")
+	_, unsupportedErr := HumanDuration(42)
+
+	// then
+	assert.Nil(t, stringErr)
+	assert.Equal(t, "3h10m", fromString)
+	assert.Nil(t, timeErr)
+	assert.Equal(t, "3h10m", fromTime)
+	assert.Nil(t, emptyErr)
+	assert.Empty(t, empty)
+	assert.Error(t, parseErr)
+	assert.ErrorIs(t, unsupportedErr, ErrUnsupportedValue)
+}
+
+func TestCondition(t *testing.T) {
+	// given
+	template := TemplateParse(`{{ with condition "Ready" . }}{{ .status }}/{{ .reason }}{{ end }}`)
+	data := map[string]any{"status": map[string]any{"conditions": []any{
+		map[string]any{"type": "Available", "status": "True"},
+		map[string]any{"type": "Ready", "status": "False", "reason": "ContainersNotReady"},
+	}}}
+
+	// when
+	result, err := TemplateExecute(template, data)
+	missing := Condition("Progressing", data)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, "False/ContainersNotReady", string(result))
+	assert.Nil(t, missing)
+}
+
+func TestOwnerOf(t *testing.T) {
+	// given
+	template := TemplateParse(`{{ with ownerOf . }}{{ .kind }}/{{ .name }}{{ end }}`)
+	owned := map[string]any{"metadata": map[string]any{"ownerReferences": []any{
+		map[string]any{"apiVersion": "v1", "kind": "ConfigMap", "name": "settings"},
+		map[string]any{"apiVersion": "apps/v1", "kind": "Deployment", "name": "my-app", "controller": true},
+	}}}
+	notControlled := map[string]any{"metadata": map[string]any{"ownerReferences": []any{
+		map[string]any{"apiVersion": "v1", "kind": "ConfigMap", "name": "settings"},
+	}}}
+
+	// when
+	result, err := TemplateExecute(template, owned)
+	firstOwner := OwnerOf(notControlled)
+	noOwner := OwnerOf(map[string]any{"metadata": map[string]any{}})
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, "Deployment/my-app", string(result))
+	assert.Equal(t, "settings", firstOwner["name"])
+	assert.Nil(t, noOwner)
+}
+
 func TestMust(t *testing.T) {
 	// given
 	err := errors.New("test")
@@ -79,3 +289,14 @@ func TestMapContains(t *testing.T) {
 	assert.True(t, contains)
 	assert.False(t, notContains)
 }
+
+func TestRecover(t *testing.T) {
+	// when
+	panicErr := Recover(func() { Must(ErrUnsupportedValue) })
+	noErr := Recover(func() {})
+
+	// then
+	assert.ErrorIs(t, panicErr, ErrPanicked)
+	assert.ErrorIs(t, panicErr, ErrUnsupportedValue)
+	assert.Nil(t, noErr)
+}
@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"github.com/nccloud/watchtower/pkg/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type ConditionTransition struct {
+	Type               string
+	From               string
+	To                 string
+	Reason             string
+	Message            string
+	LastTransitionTime string
+}
+
+func (r *Controller) transitioned(oldObj, newObj client.Object) bool {
+	return r.watcher.Spec.Filter.Event.ConditionTransition == nil || r.conditionTransition(oldObj, newObj) != nil
+}
+
+func (r *Controller) conditionTransition(oldObj, newObj client.Object) *ConditionTransition {
+	var (
+		filter                 = r.watcher.Spec.Filter.Event.ConditionTransition
+		oldObject, isOldObject = oldObj.(*unstructured.Unstructured)
+		newObject, isNewObject = newObj.(*unstructured.Unstructured)
+	)
+
+	if filter == nil || !isOldObject || !isNewObject {
+		return nil
+	}
+
+	newCondition := findCondition(newObject, filter.Type)
+	if newCondition == nil {
+		return nil
+	}
+
+	transition := &ConditionTransition{Type: filter.Type}
+	transition.To, _, _ = unstructured.NestedString(newCondition, "status")
+	transition.Reason, _, _ = unstructured.NestedString(newCondition, "reason")
+	transition.Message, _, _ = unstructured.NestedString(newCondition, "message")
+	transition.LastTransitionTime, _, _ = unstructured.NestedString(newCondition, "lastTransitionTime")
+
+	if oldCondition := findCondition(oldObject, filter.Type); oldCondition != nil {
+		transition.From, _, _ = unstructured.NestedString(oldCondition, "status")
+	}
+
+	if transition.From == transition.To || !filter.Matches(transition.From, transition.To, transition.Reason) {
+		return nil
+	}
+
+	return transition
+}
+
+func findCondition(obj *unstructured.Unstructured, conditionType string) map[string]interface{} {
+	return common.Condition(conditionType, obj.Object)
+}
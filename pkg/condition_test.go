@@ -0,0 +1,122 @@
+package pkg
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func newConditionObject(status, reason string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "my-deployment", "namespace": "my-namespace"},
+	}}
+
+	if status != "" {
+		obj.Object["status"] = map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Progressing", "status": "True"},
+				map[string]interface{}{"type": "Available", "status": status, "reason": reason},
+			},
+		}
+	}
+
+	return obj
+}
+
+func TestController_FilterEvent_ConditionTransition(t *testing.T) {
+	// given
+	var (
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Filter: v1alpha1.Filter{
+					Event: v1alpha1.EventFilter{
+						ConditionTransition: &v1alpha1.ConditionTransitionFilter{
+							Type:   "Available",
+							To:     ptr.To("False"),
+							Reason: ptr.To("^Minimum.*"),
+						},
+					},
+				},
+			},
+		}).Compile()
+		predicate = NewController(new(client2.MockClient), &http.Client{}, watcher).FilterEvent()
+		available = newConditionObject("True", "MinimumReplicasAvailable")
+	)
+
+	// when
+	created := predicate.Create(event.CreateEvent{Object: available})
+	unavailable := predicate.Update(event.UpdateEvent{
+		ObjectOld: available, ObjectNew: newConditionObject("False", "MinimumReplicasUnavailable"),
+	})
+	otherReason := predicate.Update(event.UpdateEvent{
+		ObjectOld: available, ObjectNew: newConditionObject("False", "ProgressDeadlineExceeded"),
+	})
+	unchanged := predicate.Update(event.UpdateEvent{ObjectOld: available, ObjectNew: available})
+	recovered := predicate.Update(event.UpdateEvent{
+		ObjectOld: newConditionObject("False", "MinimumReplicasUnavailable"), ObjectNew: available,
+	})
+	appeared := predicate.Update(event.UpdateEvent{
+		ObjectOld: newConditionObject("", ""), ObjectNew: newConditionObject("False", "MinimumReplicasUnavailable"),
+	})
+
+	// then
+	assert.False(t, created)
+	assert.True(t, unavailable)
+	assert.False(t, otherReason)
+	assert.False(t, unchanged)
+	assert.False(t, recovered)
+	assert.True(t, appeared)
+}
+
+func TestController_Send_ConditionTransition(t *testing.T) {
+	// given
+	var (
+		ctx    = context.Background()
+		body   []byte
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = io.ReadAll(r.Body)
+		}))
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Filter: v1alpha1.Filter{
+					Event: v1alpha1.EventFilter{
+						ConditionTransition: &v1alpha1.ConditionTransitionFilter{Type: "Available"},
+					},
+				},
+				Destination: v1alpha1.Destination{
+					URLTemplate: server.URL,
+					Method:      "POST",
+					BodyTemplate: "{{ .metadata.name }} {{ .Transition.Type }} {{ .Transition.From }} -> " +
+						"{{ .Transition.To }} ({{ .Transition.Reason }}) {{ toJson .status.conditions }}",
+				},
+			},
+		}).Compile()
+		controller  = NewController(new(client2.MockClient), server.Client(), watcher)
+		unavailable = newConditionObject("False", "MinimumReplicasUnavailable")
+	)
+	defer server.Close()
+
+	// when
+	controller.TrackEvent().Update(event.UpdateEvent{
+		ObjectOld: newConditionObject("True", "MinimumReplicasAvailable"), ObjectNew: unavailable,
+	})
+	sendErr := controller.Send(ctx, unavailable)
+	data, marshalErr := controller.TemplateData(unavailable).MarshalJSON()
+
+	// then
+	assert.Nil(t, sendErr)
+	assert.Equal(t, "my-deployment Available True -> False (MinimumReplicasUnavailable) "+
+		`[{"status":"True","type":"Progressing"},{"reason":"MinimumReplicasUnavailable","status":"False",`+
+		`"type":"Available"}]`, string(body))
+	assert.Nil(t, marshalErr)
+	assert.NotContains(t, string(data), TemplateKeyTransition)
+}
@@ -5,65 +5,210 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 
 	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
 	"github.com/nccloud/watchtower/pkg/common"
 
+	v1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 var ErrUnexpectedStatusCode = errors.New("unexpected status code")
 
+type StatusCodeError struct {
+	StatusCode int
+}
+
+func (e *StatusCodeError) Error() string {
+	return fmt.Sprintf("%s: %d", ErrUnexpectedStatusCode, e.StatusCode)
+}
+
+func (e *StatusCodeError) Unwrap() error {
+	return ErrUnexpectedStatusCode
+}
+
+func (e *StatusCodeError) statusCode() int {
+	return e.StatusCode
+}
+
 type Controller struct {
-	client     client.Client
-	watcher    *v1alpha1.Watcher
-	httpClient *http.Client
+	client client.Client
+	// statusClient updates the status of the watcher, while the client reads the objects of the source.
+	statusClient client.Client
+	watcher      *v1alpha1.Watcher
+	source       *v1alpha1.Source
+	httpClient   *http.Client
+	writer       io.Writer
+	writerLock   *sync.Mutex
+	tombstones   *tombstones
+	events       sync.Map
+	batcher      *Batcher
+	limiter      *RateLimiter
+	rateLimiters *RateLimiters
+	breaker      *CircuitBreaker
+	// circuitStates is the latest state of the circuit breaker to be reported in the status of the watcher.
+	circuitStates chan CircuitState
+	aggregator    *EventAggregator
+	pending       *keySet
+	// unreachable are the remote clusters of the sources that can't be reached.
+	unreachable *keySet
+	cluster     *RemoteCluster
+	clusters    map[string]*RemoteCluster
+	// discovery checks whether the kinds of the sources are served when it's set, in every discoveryPeriod.
+	discovery       discovery.ServerResourcesInterface
+	discoveryPeriod time.Duration
+	// readsObject is whether the full objects are read although only the metadata of the source is watched.
+	readsObject bool
+	// relatedReader reads the owners and the referencing objects of the filters from the related cache of the cluster
+	// of the source.
+	relatedReader client.Reader
+	// apiReader reads the namespaces of the objects from the API server as the service account of the watcher.
+	apiReader client.Reader
+	// relatedCache is the related cache of the local cluster.
+	relatedCache cache.Cache
+	// lookupKinds are the kinds of the objects the templates can read with lookup.
+	lookupKinds []schema.GroupKind
+	// templates are the copies of the templates of the watcher with the lookup of the controller.
+	templates sync.Map
+	// lookupsWaited are the kinds whose informers the lookups waited for to sync once.
+	lookupsWaited sync.Map
+	// values are the values of the templates, which are reloaded in place when the config maps are changed.
+	values *Values
 }
 
 func NewController(client client.Client, httpClient *http.Client, watcher *v1alpha1.Watcher) *Controller {
-	return &Controller{
-		client:     client,
-		httpClient: httpClient,
-		watcher:    watcher,
+	controller := &Controller{
+		client:       client,
+		statusClient: client,
+		httpClient:   httpClient,
+		watcher:      watcher,
+		source:       &watcher.Spec.Compiled.Sources[0],
+		writer:       newWriter(watcher.Spec.Destination),
+		writerLock:   &sync.Mutex{},
+		tombstones:   newTombstones(),
+		pending:      newKeySet(),
+		unreachable:  newKeySet(),
+		aggregator:   newEventAggregator(&watcher.Spec.Compiled.Sources[0]),
+		rateLimiters: NewRateLimiters(),
+		values:       NewValues(watcher.Spec.Values),
+	}
+	controller.readsObject = controller.readsMoreThanMetadata()
+
+	if rateLimit := watcher.Spec.Destination.RateLimit; rateLimit != nil {
+		controller.limiter = NewRateLimiter(rateLimit.Compiled.RequestsPerSecond, rateLimit.GetBurst())
+	}
+
+	if breaker := watcher.Spec.Destination.CircuitBreaker; breaker != nil {
+		controller.circuitStates = make(chan CircuitState, 1)
+		controller.breaker = NewCircuitBreaker(breaker.GetFailureThreshold(), breaker.Compiled.OpenDuration,
+			breaker.GetHalfOpenProbes(), controller.onCircuitStateChange)
+	}
+
+	if batch := watcher.Spec.Destination.Batch; batch != nil && !watcher.Spec.Destination.IsLineBased() {
+		controller.batcher = NewBatcher(batch.GetMaxItems(), batch.Compiled.MaxBytes, batch.Compiled.MaxWait,
+			controller.SendItems)
 	}
+
+	return controller
+}
+
+// ForSource returns a controller of another source of the watcher, sharing the destination with this one.
+func (r *Controller) ForSource(source *v1alpha1.Source) *Controller {
+	controller := &Controller{
+		client:       r.client,
+		statusClient: r.statusClient,
+		watcher:      r.watcher,
+		source:       source,
+		httpClient:   r.httpClient,
+		writer:       r.writer,
+		writerLock:   r.writerLock,
+		batcher:      r.batcher,
+		limiter:      r.limiter,
+		rateLimiters: r.rateLimiters,
+		breaker:      r.breaker,
+		aggregator:   newEventAggregator(source),
+		tombstones:   newTombstones(),
+		pending:      r.pending,
+		unreachable:  r.unreachable,
+		clusters:     r.clusters,
+
+		discovery:       r.discovery,
+		discoveryPeriod: r.discoveryPeriod,
+		lookupKinds:     r.lookupKinds,
+		values:          r.values,
+		relatedCache:    r.relatedCache,
+	}
+	controller.readsObject = controller.readsMoreThanMetadata()
+
+	return controller
 }
 
 func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	var (
-		start  = time.Now()
-		logger = log.FromContext(ctx)
-		obj    = r.watcher.Spec.Source.NewObject()
+		start       = time.Now()
+		logger      = log.FromContext(ctx)
+		obj         = r.source.NewUnstructured()
+		objectEvent = r.lastEvent(req.NamespacedName)
 	)
 
-	if getErr := r.client.Get(ctx, req.NamespacedName, obj); getErr != nil {
-		return ctrl.Result{}, client.IgnoreNotFound(getErr)
+	if wait := r.debounce(objectEvent); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	defer r.events.CompareAndDelete(req.NamespacedName, objectEvent)
+
+	if getErr := r.getObject(ctx, req.NamespacedName, obj); getErr != nil {
+		if client.IgnoreNotFound(getErr) != nil {
+			return ctrl.Result{}, getErr
+		}
+
+		return resultFor(r.Remove(ctx, req.NamespacedName, ""))
+	}
+
+	if finalized, removeErr := r.settleRemovals(ctx, obj); removeErr != nil || finalized {
+		return resultFor(removeErr)
 	}
 
-	if filtered, filterErr := r.FilterObject(obj); filterErr != nil || filtered {
+	if filtered, filterErr := r.FilterObject(ctx, obj); filterErr != nil || filtered {
 		return ctrl.Result{}, filterErr
 	}
 
+	data := r.TemplateData(obj)
+	if send, wait, aggregateErr := r.aggregateEvent(ctx, obj, objectEvent, data); aggregateErr != nil || !send {
+		return ctrl.Result{RequeueAfter: wait}, aggregateErr
+	}
+
 	logger.Info("Started")
 
-	if sendErr := r.Send(ctx, obj); sendErr != nil {
-		return ctrl.Result{}, sendErr
+	if sendErr := r.SendData(ctx, obj, data); sendErr != nil {
+		r.restoreEvent(obj, data)
+
+		return resultFor(sendErr)
 	}
 
-	if r.watcher.Spec.Source.Options.OnSuccess.DeleteObject {
-		deleteErr := r.client.Delete(ctx, obj, client.PropagationPolicy("Background"))
-		if client.IgnoreNotFound(deleteErr) != nil {
-			return ctrl.Result{}, deleteErr
-		}
+	if deleteErr := r.deleteSent(ctx, obj); deleteErr != nil {
+		return ctrl.Result{}, deleteErr
 	}
 
 	logger.Info("Finished", "duration", time.Since(start).String())
@@ -71,18 +216,218 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	return ctrl.Result{}, nil
 }
 
+// deleteSent deletes the sent object when the source deletes the objects on success.
+func (r *Controller) deleteSent(ctx context.Context, obj *unstructured.Unstructured) error {
+	if !r.source.Options.OnSuccess.DeleteObject {
+		return nil
+	}
+
+	return client.IgnoreNotFound(r.client.Delete(ctx, obj, client.PropagationPolicy("Background")))
+}
+
 func (r *Controller) Send(ctx context.Context, obj *unstructured.Unstructured) error {
-	url, urlErr := common.TemplateExecuteForObject(r.watcher.Spec.Destination.Compiled.URLTemplate, obj)
-	if urlErr != nil {
-		return urlErr
+	return r.SendData(ctx, obj, r.TemplateData(obj))
+}
+
+// SendData sends the object with the template data, which may have more keys than its own.
+func (r *Controller) SendData(ctx context.Context, obj *unstructured.Unstructured, data TemplateData) error {
+	if r.watcher.Spec.Destination.Diff != nil {
+		patch, diffErr := r.Diff(obj)
+		if diffErr != nil {
+			return diffErr
+		}
+
+		if patch == "" || patch == common.EmptyJSONPatch || patch == common.EmptyMergePatch {
+			return nil
+		}
+
+		data[TemplateKeyPatch] = patch
 	}
 
-	body, bodyErr := common.TemplateExecuteForObject(r.watcher.Spec.Destination.Compiled.BodyTemplate, obj)
+	body, bodyErr := r.executeTemplate(r.watcher.Spec.Destination.Compiled.BodyTemplate, data)
 	if bodyErr != nil {
 		return bodyErr
 	}
 
-	headers, headersErr := common.TemplateExecuteForObject(r.watcher.Spec.Destination.Compiled.HeaderTemplate, obj)
+	if r.watcher.Spec.Destination.IsLineBased() {
+		return r.WriteLine(body)
+	}
+
+	item := &BatchItem{Object: obj, Body: body}
+
+	if r.watcher.Spec.Destination.Type == v1alpha1.DestinationTypeElasticsearch {
+		operation, operationErr := r.NewBulkOperation(obj, BulkActionIndex, body)
+		if operationErr != nil {
+			return operationErr
+		}
+
+		item.Operation = operation
+	}
+
+	return r.Deliver(ctx, item)
+}
+
+// Remove deletes the documents of the deleted objects of the key, except the object with the uid which exists.
+func (r *Controller) Remove(ctx context.Context, key types.NamespacedName, uid types.UID) error {
+	var errs []error
+
+	for _, obj := range r.tombstones.take(key, uid) {
+		if removeErr := r.remove(ctx, obj); removeErr != nil {
+			r.tombstones.store(obj)
+
+			errs = append(errs, removeErr)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// settleRemovals removes the documents of the objects deleted before the object was recreated under the same name, and
+// finalizes the object when it's being deleted, returning whether it was finalized.
+func (r *Controller) settleRemovals(ctx context.Context, obj *unstructured.Unstructured) (bool, error) {
+	if removeErr := r.Remove(ctx, client.ObjectKeyFromObject(obj), obj.GetUID()); removeErr != nil {
+		return false, removeErr
+	}
+
+	if !r.finalizes() {
+		return false, nil
+	}
+
+	if obj.GetDeletionTimestamp() != nil {
+		return true, r.finalize(ctx, obj)
+	}
+
+	return false, r.addFinalizer(ctx, obj)
+}
+
+func (r *Controller) remove(ctx context.Context, obj *unstructured.Unstructured) error {
+	if filtered, filterErr := r.FilterObject(ctx, obj); filterErr != nil || filtered {
+		return filterErr
+	}
+
+	log.FromContext(ctx).Info("Removing", "uid", obj.GetUID())
+
+	operation, operationErr := r.NewBulkOperation(obj, BulkActionDelete, nil)
+	if operationErr != nil {
+		return operationErr
+	}
+
+	return r.Deliver(ctx, &BatchItem{Object: obj, Operation: operation})
+}
+
+func (r *Controller) Deliver(ctx context.Context, item *BatchItem) error {
+	if r.breaker == nil {
+		return r.deliver(ctx, item)
+	}
+
+	permit, allowErr := r.breaker.Allow()
+	if allowErr != nil {
+		return allowErr
+	}
+
+	deliverErr := r.deliver(ctx, item)
+	r.recordDelivery(permit, deliverErr)
+
+	return deliverErr
+}
+
+func (r *Controller) deliver(ctx context.Context, item *BatchItem) error {
+	if r.batcher != nil {
+		return r.batcher.Add(ctx, item)
+	}
+
+	return r.SendItems(ctx, []*BatchItem{item})[0]
+}
+
+// SendItems sends the items rendered to the same URL with the same headers together.
+func (r *Controller) SendItems(ctx context.Context, items []*BatchItem) []error {
+	errs := make([]error, len(items))
+
+	for _, group := range r.groupItems(items, errs) {
+		for index, sendErr := range r.sendItems(ctx, group.items) {
+			errs[group.indexes[index]] = sendErr
+		}
+	}
+
+	return errs
+}
+
+// itemGroup is the items that are sent to the same URL with the same headers, and their indexes in the batch.
+type itemGroup struct {
+	indexes []int
+	items   []*BatchItem
+}
+
+// groupItems groups the items by their rendered URL and headers in order, setting the errors of the items whose
+// templates can't be rendered.
+func (r *Controller) groupItems(items []*BatchItem, errs []error) []*itemGroup {
+	var (
+		groups []*itemGroup
+		byKey  = map[string]*itemGroup{}
+	)
+
+	for index, item := range items {
+		url, urlErr := r.TemplateExecute(r.watcher.Spec.Destination.Compiled.URLTemplate, item.Object)
+		headers, headersErr := r.TemplateExecute(r.watcher.Spec.Destination.Compiled.HeaderTemplate, item.Object)
+
+		if renderErr := errors.Join(urlErr, headersErr); renderErr != nil {
+			errs[index] = renderErr
+
+			continue
+		}
+
+		key := string(url) + "\n" + string(headers)
+
+		group := byKey[key]
+		if group == nil {
+			group = &itemGroup{}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+
+		group.indexes = append(group.indexes, index)
+		group.items = append(group.items, item)
+	}
+
+	return groups
+}
+
+func (r *Controller) sendItems(ctx context.Context, items []*BatchItem) []error {
+	if r.watcher.Spec.Destination.Type == v1alpha1.DestinationTypeElasticsearch {
+		operations := make([]BulkOperation, 0, len(items))
+		for _, item := range items {
+			operations = append(operations, item.Operation)
+		}
+
+		return r.SendBulk(ctx, items[0].Object, operations)
+	}
+
+	body := items[0].Body
+
+	if batch := r.watcher.Spec.Destination.Batch; batch != nil {
+		bodies := make([]string, 0, len(items))
+		for _, item := range items {
+			bodies = append(bodies, string(item.Body))
+		}
+
+		wrapped, wrapErr := r.executeTemplate(batch.Compiled.Template, map[string]any{"items": bodies})
+		if wrapErr != nil {
+			return repeatError(wrapErr, len(items))
+		}
+
+		body = wrapped
+	}
+
+	return repeatError(r.SendHTTP(ctx, items[0].Object, body), len(items))
+}
+
+func (r *Controller) SendHTTP(ctx context.Context, obj *unstructured.Unstructured, body []byte) error {
+	url, urlErr := r.TemplateExecute(r.watcher.Spec.Destination.Compiled.URLTemplate, obj)
+	if urlErr != nil {
+		return urlErr
+	}
+
+	headers, headersErr := r.TemplateExecute(r.watcher.Spec.Destination.Compiled.HeaderTemplate, obj)
 	if headersErr != nil {
 		return headersErr
 	}
@@ -95,7 +440,7 @@ func (r *Controller) Send(ctx context.Context, obj *unstructured.Unstructured) e
 
 	request.Header = common.StringToMap(string(headers))
 
-	doRequest, doRequestErr := r.httpClient.Do(request)
+	doRequest, doRequestErr := r.Do(request)
 	if doRequestErr != nil {
 		return doRequestErr
 	}
@@ -105,15 +450,28 @@ func (r *Controller) Send(ctx context.Context, obj *unstructured.Unstructured) e
 	}()
 
 	if doRequest.StatusCode < 200 || doRequest.StatusCode >= 300 {
-		return fmt.Errorf("%w: %d", ErrUnexpectedStatusCode, doRequest.StatusCode)
+		return &StatusCodeError{StatusCode: doRequest.StatusCode}
 	}
 
 	return nil
 }
 
+func (r *Controller) WriteLine(body []byte) error {
+	r.writerLock.Lock()
+	defer r.writerLock.Unlock()
+
+	_, writeErr := r.writer.Write(append(bytes.TrimRight(body, "\r\n"), '\n'))
+
+	return writeErr
+}
+
 func (r *Controller) FilterEvent() predicate.Funcs {
 	return predicate.Funcs{
 		CreateFunc: func(event event.CreateEvent) bool {
+			if r.watcher.Spec.Filter.Event.ConditionTransition != nil {
+				return false
+			}
+
 			if r.watcher.Spec.Filter.Event.Create.CreationTimeout != nil {
 				return event.Object.GetCreationTimestamp().
 					Add(r.watcher.Spec.Filter.Event.Create.Compiled.CreationTimeout).After(time.Now())
@@ -122,6 +480,11 @@ func (r *Controller) FilterEvent() predicate.Funcs {
 			return true
 		},
 		UpdateFunc: func(updateEvent event.UpdateEvent) bool {
+			if !r.fieldsChanged(updateEvent.ObjectOld, updateEvent.ObjectNew) ||
+				!r.transitioned(updateEvent.ObjectOld, updateEvent.ObjectNew) {
+				return false
+			}
+
 			if r.watcher.Spec.Filter.Event.Update.GenerationChanged != nil {
 				if *r.watcher.Spec.Filter.Event.Update.GenerationChanged {
 					return updateEvent.ObjectOld.GetGeneration() != updateEvent.ObjectNew.GetGeneration()
@@ -143,49 +506,211 @@ func (r *Controller) FilterEvent() predicate.Funcs {
 	}
 }
 
-func (r *Controller) FilterObject(obj *unstructured.Unstructured) (bool, error) {
-	if r.watcher.Spec.Filter.Object.Name != nil &&
-		!r.watcher.Spec.Filter.Object.Compiled.Name.MatchString(obj.GetName()) {
+func (r *Controller) tracksRemovals() bool {
+	return r.watcher.Spec.Destination.Type == v1alpha1.DestinationTypeElasticsearch &&
+		!r.source.Options.OnSuccess.DeleteObject && !r.finalizes()
+}
+
+// finalizes is whether the documents of the objects are deleted by the finalizer of the watcher instead of tombstones.
+func (r *Controller) finalizes() bool {
+	return r.watcher.Spec.Destination.Type == v1alpha1.DestinationTypeElasticsearch &&
+		r.watcher.Spec.Destination.Elasticsearch != nil && r.watcher.Spec.Destination.Elasticsearch.Finalize &&
+		!r.source.Options.OnSuccess.DeleteObject
+}
+
+func (r *Controller) FilterObject(ctx context.Context, obj *unstructured.Unstructured) (bool, error) {
+	if r.filterSource(obj) {
 		return true, nil
 	}
 
-	if r.watcher.Spec.Filter.Object.Namespace != nil &&
-		!r.watcher.Spec.Filter.Object.Compiled.Namespace.MatchString(obj.GetNamespace()) {
-		return true, nil
+	matched, matchErr := r.MatchObject(ctx, &r.watcher.Spec.Filter.Object, obj)
+
+	return !matched, matchErr
+}
+
+func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	if addErr := r.addRunnables(mgr); addErr != nil {
+		return addErr
 	}
 
-	if r.watcher.Spec.Filter.Object.Labels != nil &&
-		!common.MapContains(obj.GetLabels(), *r.watcher.Spec.Filter.Object.Labels) {
-		return true, nil
+	var (
+		sources        = r.watcher.Spec.Compiled.Sources
+		controllers    = make([]*Controller, 0, len(sources))
+		unreachableErr error
+	)
+
+	for index := range sources {
+		controller, controllerErr := r.controllerFor(&sources[index])
+		if errors.Is(controllerErr, ErrClusterNotFound) {
+			// The kubeconfig of the cluster is missing or unreadable, or its cluster couldn't be set up, so only the
+			// sources in the cluster are skipped.
+			unreachable := r.ForSource(&sources[index])
+
+			reportUnreachable := manager.RunnableFunc(func(ctx context.Context) error {
+				return unreachable.setClusterReachable(ctx, false)
+			})
+			if addErr := mgr.Add(reportUnreachable); addErr != nil {
+				return addErr
+			}
+
+			unreachableErr = errors.Join(unreachableErr, controllerErr)
+
+			continue
+		}
+
+		if controllerErr != nil {
+			return controllerErr
+		}
+
+		controllers = append(controllers, controller)
 	}
 
-	if r.watcher.Spec.Filter.Object.Annotations != nil &&
-		!common.MapContains(obj.GetAnnotations(), *r.watcher.Spec.Filter.Object.Annotations) {
-		return true, nil
+	for _, controller := range controllers {
+		if setupErr := controller.setupSource(mgr); setupErr != nil {
+			return setupErr
+		}
 	}
 
-	if r.watcher.Spec.Filter.Object.Custom != nil {
-		result, executeErr := common.TemplateExecuteForObject(
-			r.watcher.Spec.Filter.Object.Custom.Compiled.Template, obj)
-		if executeErr != nil {
-			return true, executeErr
+	return unreachableErr
+}
+
+// controllerFor returns the controller of the source, which reads the objects from its cluster as the service
+// account of the watcher.
+func (r *Controller) controllerFor(source *v1alpha1.Source) (*Controller, error) {
+	key := clusterKey(r.watcher, source)
+	if key == "" && source == r.source {
+		return r, nil
+	}
+
+	controller := r.ForSource(source)
+	if key == "" {
+		return controller, nil
+	}
+
+	remote, remoteErr := r.remoteCluster(source)
+	if remoteErr != nil {
+		return nil, remoteErr
+	}
+
+	controller.client, controller.cluster = remote.GetClient(), remote
+	if controller.discovery != nil {
+		controller.discovery = remote.discovery
+	}
+
+	return controller, nil
+}
+
+func (r *Controller) setupSource(mgr ctrl.Manager) error {
+	r.relatedReader = r.relatedObjects(mgr)
+	if r.apiReader = mgr.GetAPIReader(); r.cluster != nil {
+		r.apiReader = r.cluster.GetAPIReader()
+	}
+
+	if r.discovery != nil {
+		return mgr.Add(&sourceRunner{controller: r, manager: mgr})
+	}
+
+	managedBy := ctrl.NewControllerManagedBy(mgr).
+		Named(r.name()).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.concurrency(),
+		})
+
+	for _, watchSource := range r.watchSources(r.sourceCache(mgr), r.relatedObjects(mgr)) {
+		managedBy = managedBy.WatchesRawSource(watchSource)
+	}
+
+	return managedBy.Complete(r)
+}
+
+// addRunnables adds the runnables of the destination shared by the sources of the watcher.
+func (r *Controller) addRunnables(mgr ctrl.Manager) error {
+	if r.batcher != nil {
+		if addErr := mgr.Add(r.batcher); addErr != nil {
+			return addErr
 		}
+	}
 
-		if string(result) != r.watcher.Spec.Filter.Object.Custom.Result {
-			return true, nil
+	if r.breaker != nil {
+		if addErr := mgr.Add(manager.RunnableFunc(r.reportCircuitStates)); addErr != nil {
+			return addErr
 		}
 	}
 
-	return false, nil
+	if closer, isCloser := r.writer.(io.Closer); isCloser {
+		return mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+
+			return closer.Close()
+		}))
+	}
+
+	return nil
 }
 
-func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		Named(r.watcher.GetName()).
-		WithEventFilter(r.FilterEvent()).
-		WithOptions(controller.Options{
-			MaxConcurrentReconciles: r.watcher.Spec.GetConcurrency(),
-		}).
-		For(r.watcher.Spec.Source.NewObject()).
-		Complete(r)
+// concurrency is the concurrency of the source, which is the maximum items of the batch by default, so that the workers
+// waiting for their batch to be sent can fill it.
+func (r *Controller) concurrency() int {
+	if r.batcher != nil && r.source.Concurrency == nil {
+		return r.watcher.Spec.Destination.Batch.GetMaxItems()
+	}
+
+	return r.source.GetConcurrency()
+}
+
+// watchSources are the sources of the events of the objects, and of their namespaces when they're selected by labels,
+// which are watched in the related cache.
+func (r *Controller) watchSources(sourceCache, relatedCache cache.Cache) []source.Source {
+	sources := []source.Source{
+		source.Kind(sourceCache, r.source.NewObject(), &handler.EnqueueRequestForObject{},
+			r.FilterEvent(), r.TrackEvent()),
+	}
+
+	if r.watcher.Spec.Filter.Object.HasNamespaceSelector() {
+		sources = append(sources, &namespacesSource{
+			SyncingSource: source.Kind(relatedCache, client.Object(&v1.Namespace{}),
+				handler.EnqueueRequestsFromMapFunc(r.NamespaceRequests(sourceCache)),
+				NamespaceSelected(&r.watcher.Spec.Filter.Object)),
+			controller: r,
+		})
+	}
+
+	return sources
+}
+
+// name is the key of the watcher, suffixed with the kind of the source when the watcher has more sources.
+func (r *Controller) name() string {
+	if len(r.watcher.Spec.Compiled.Sources) == 1 {
+		return r.watcher.Key()
+	}
+
+	name := fmt.Sprintf("%s-%s", r.watcher.Key(),
+		strings.ToLower(r.source.GroupVersionKind().GroupKind().String()))
+	if r.source.Cluster != nil {
+		name += "-" + r.source.Cluster.GetName()
+	}
+
+	return name
+}
+
+func resultFor(err error) (ctrl.Result, error) {
+	var circuitOpenErr *CircuitOpenError
+	if errors.As(err, &circuitOpenErr) {
+		return ctrl.Result{RequeueAfter: circuitOpenErr.RetryAfter}, nil
+	}
+
+	return ctrl.Result{}, err
+}
+
+func newWriter(destination v1alpha1.Destination) io.Writer {
+	if destination.Type == v1alpha1.DestinationTypeStdout {
+		return os.Stdout
+	}
+
+	if destination.Type == v1alpha1.DestinationTypeFile && destination.File != nil {
+		return common.SharedRotatingFile(destination.File.Path, destination.File.Compiled.MaxSize,
+			destination.File.GetMaxBackups(), destination.File.Compress)
+	}
+
+	return io.Discard
 }
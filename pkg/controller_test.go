@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -43,6 +44,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 var testVars = struct {
@@ -636,6 +638,215 @@ func TestController_Reconcile_FilterByCustom(t *testing.T) {
 	assert.False(t, result.Requeue)
 }
 
+func TestController_FilterObject_Selectors(t *testing.T) {
+	// given
+	var (
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Filter: v1alpha1.Filter{
+					Object: v1alpha1.ObjectFilter{
+						LabelSelector: &metav1.LabelSelector{
+							MatchExpressions: []metav1.LabelSelectorRequirement{
+								{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"web", "api"}},
+								{Key: "skip", Operator: metav1.LabelSelectorOpDoesNotExist},
+							},
+						},
+						AnnotationSelector: &metav1.LabelSelector{
+							MatchExpressions: []metav1.LabelSelectorRequirement{
+								{Key: "owner", Operator: metav1.LabelSelectorOpExists},
+							},
+						},
+					},
+				},
+			},
+		}).Compile()
+		controller = NewController(new(client2.MockClient), &http.Client{}, watcher)
+		newObject  = func(labels, annotations map[string]string) *unstructured.Unstructured {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			obj.SetLabels(labels)
+			obj.SetAnnotations(annotations)
+
+			return obj
+		}
+	)
+
+	// when
+	matched, _ := controller.FilterObject(context.Background(), newObject(map[string]string{"tier": "api"},
+		map[string]string{"owner": "team"}))
+	wrongTier, _ := controller.FilterObject(context.Background(), newObject(map[string]string{"tier": "db"},
+		map[string]string{"owner": "team"}))
+	skipped, _ := controller.FilterObject(context.Background(), newObject(map[string]string{"tier": "web", "skip": "true"},
+		map[string]string{"owner": "team"}))
+	withoutOwner, _ := controller.FilterObject(context.Background(), newObject(map[string]string{"tier": "web"}, nil))
+
+	// then
+	assert.False(t, matched)
+	assert.True(t, wrongTier)
+	assert.True(t, skipped)
+	assert.True(t, withoutOwner)
+}
+
+func TestController_FilterObject_CEL(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Filter: v1alpha1.Filter{
+					Object: v1alpha1.ObjectFilter{
+						CEL: ptr.To(`eventType == "update" && object.data.key != oldObject.data.key`),
+					},
+				},
+			},
+		}).Compile()
+		controller = NewController(new(client2.MockClient), &http.Client{}, watcher)
+		newSecret  = func(value string) *unstructured.Unstructured {
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "my-secret", "namespace": "my-namespace"},
+				"data":     map[string]interface{}{"key": value},
+			}}
+		}
+		oldSecret     = newSecret("old")
+		updatedSecret = newSecret("new")
+	)
+
+	// when
+	controller.TrackEvent().Create(event.CreateEvent{Object: oldSecret})
+	created, createdErr := controller.FilterObject(ctx, oldSecret)
+	controller.TrackEvent().Update(event.UpdateEvent{ObjectOld: oldSecret, ObjectNew: updatedSecret})
+	changed, changedErr := controller.FilterObject(ctx, updatedSecret)
+	controller.TrackEvent().Update(event.UpdateEvent{ObjectOld: updatedSecret, ObjectNew: updatedSecret})
+	debounced, _ := controller.FilterObject(ctx, updatedSecret)
+	controller.events.Clear()
+	controller.TrackEvent().Update(event.UpdateEvent{ObjectOld: updatedSecret, ObjectNew: updatedSecret})
+	unchanged, _ := controller.FilterObject(ctx, updatedSecret)
+	controller.events.Clear()
+	resynced, resyncedErr := controller.FilterObject(ctx, updatedSecret)
+	watcher.Spec.Filter.Object.CEL = ptr.To(`object.data.key != oldObject.data.key`)
+	watcher.Spec.Filter.Object.Compile()
+	controller.TrackEvent().Create(event.CreateEvent{Object: updatedSecret})
+	createdWithoutOld, createdWithoutOldErr := controller.FilterObject(ctx, updatedSecret)
+	watcher.Spec.Filter.Object.CEL = ptr.To(`object.data.missing != oldObject.data.key`)
+	watcher.Spec.Filter.Object.Compile()
+	_, failedErr := controller.FilterObject(ctx, updatedSecret)
+
+	// then
+	assert.Nil(t, createdErr)
+	assert.Nil(t, changedErr)
+	assert.True(t, created)
+	assert.False(t, changed)
+	assert.False(t, debounced)
+	assert.True(t, unchanged)
+	assert.Nil(t, resyncedErr)
+	assert.True(t, resynced)
+	assert.Nil(t, createdWithoutOldErr)
+	assert.True(t, createdWithoutOld)
+	assert.ErrorContains(t, failedErr, "no such key: missing")
+}
+
+func TestController_Reconcile_FileDestination(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		path    = filepath.Join(t.TempDir(), "objects.jsonl")
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Destination: v1alpha1.Destination{
+					Type: v1alpha1.DestinationTypeFile,
+					File: &v1alpha1.FileDestination{
+						Path:    path,
+						MaxSize: ptr.To("1Mi"),
+					},
+				},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		secret     = &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "my-secret", "namespace": "my-namespace"},
+			},
+		}
+		controller = NewController(mockClient, &http.Client{}, watcher)
+	)
+	mockClient.EXPECT().Get(mock.Anything, client.ObjectKeyFromObject(secret),
+		mock.AnythingOfType("*unstructured.Unstructured")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			secret.DeepCopyInto(obj.(*unstructured.Unstructured))
+			return nil
+		})
+
+	// when
+	_, firstReconcileErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+	_, secondReconcileErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+
+	// then
+	assert.Nil(t, firstReconcileErr)
+	assert.Nil(t, secondReconcileErr)
+	assert.Equal(t, int64(1024*1024), watcher.Spec.Destination.File.Compiled.MaxSize)
+	content, readErr := os.ReadFile(path)
+	assert.Nil(t, readErr)
+	line := `{"metadata":{"name":"my-secret","namespace":"my-namespace"}}` + "\n"
+	assert.Equal(t, line+line, string(content))
+}
+
+func TestDestination_Validate(t *testing.T) {
+	// given
+	watcher := &v1alpha1.Watcher{
+		Spec: v1alpha1.WatcherSpec{
+			Destination: v1alpha1.Destination{Type: v1alpha1.DestinationTypeFile},
+		},
+	}
+
+	elasticsearch := v1alpha1.Destination{Type: v1alpha1.DestinationTypeElasticsearch}
+
+	// when
+	validateErr := watcher.Spec.Destination.Validate()
+	elasticsearchErr := elasticsearch.Validate()
+
+	// then
+	assert.ErrorIs(t, validateErr, v1alpha1.ErrDestinationOptionsMissing)
+	assert.ErrorIs(t, elasticsearchErr, v1alpha1.ErrDestinationOptionsMissing)
+	assert.Panics(t, func() { watcher.Compile() })
+}
+
+func TestController_Reconcile_StdoutDestination(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		output  = &bytes.Buffer{}
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Destination: v1alpha1.Destination{
+					Type:         v1alpha1.DestinationTypeStdout,
+					BodyTemplate: "{{ .metadata.name }}\n",
+				},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		secret     = &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "my-secret"},
+			},
+		}
+		controller = NewController(mockClient, &http.Client{}, watcher)
+	)
+	controller.writer = output
+	mockClient.EXPECT().Get(mock.Anything, client.ObjectKeyFromObject(secret),
+		mock.AnythingOfType("*unstructured.Unstructured")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			secret.DeepCopyInto(obj.(*unstructured.Unstructured))
+			return nil
+		})
+
+	// when
+	_, reconcileErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+
+	// then
+	assert.Nil(t, reconcileErr)
+	assert.Equal(t, "my-secret\n", output.String())
+	assert.Equal(t, os.Stdout, NewController(mockClient, &http.Client{}, watcher).writer)
+}
+
 func TestController_FilterEvent(t *testing.T) {
 	// given
 	var (
@@ -849,6 +1060,58 @@ func TestController_FilterEvent_UpdateGenerationChangedTrue(t *testing.T) {
 	assert.True(t, filtered)
 }
 
+func TestController_FilterEvent_UpdateFieldsChanged(t *testing.T) {
+	// given
+	var (
+		newPod = func(phase, heartbeat string) *unstructured.Unstructured {
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"resourceVersion": heartbeat},
+				"status": map[string]interface{}{
+					"phase": phase,
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Ready", "lastHeartbeatTime": heartbeat},
+					},
+				},
+			}}
+		}
+		newFilter = func(update v1alpha1.UpdateEventFilter) predicate.Funcs {
+			return NewController(new(client2.MockClient), &http.Client{}, (&v1alpha1.Watcher{
+				Spec: v1alpha1.WatcherSpec{
+					Filter: v1alpha1.Filter{Event: v1alpha1.EventFilter{Update: update}},
+				},
+			}).Compile()).FilterEvent()
+		}
+		fieldsChanged = newFilter(v1alpha1.UpdateEventFilter{FieldsChanged: []string{"status.phase"}})
+		ignoreFields  = newFilter(v1alpha1.UpdateEventFilter{
+			IgnoreFields: []string{"status.conditions[*].lastHeartbeatTime"},
+		})
+	)
+
+	// when
+	phaseChanged := fieldsChanged.Update(event.UpdateEvent{
+		ObjectOld: newPod("Pending", "1"), ObjectNew: newPod("Running", "2"),
+	})
+	heartbeatChanged := fieldsChanged.Update(event.UpdateEvent{
+		ObjectOld: newPod("Running", "1"), ObjectNew: newPod("Running", "2"),
+	})
+	onlyIgnoredChanged := ignoreFields.Update(event.UpdateEvent{
+		ObjectOld: newPod("Running", "1"), ObjectNew: newPod("Running", "2"),
+	})
+	notIgnoredChanged := ignoreFields.Update(event.UpdateEvent{
+		ObjectOld: newPod("Pending", "1"), ObjectNew: newPod("Running", "2"),
+	})
+	resynced := ignoreFields.Update(event.UpdateEvent{
+		ObjectOld: newPod("Running", "2"), ObjectNew: newPod("Running", "2"),
+	})
+
+	// then
+	assert.True(t, phaseChanged)
+	assert.False(t, heartbeatChanged)
+	assert.False(t, onlyIgnoredChanged)
+	assert.True(t, notIgnoredChanged)
+	assert.True(t, resynced)
+}
+
 func TestController_FilterEvent_UpdateGenerationChangedFalse(t *testing.T) {
 	// given
 	var (
@@ -895,6 +1158,7 @@ func TestController_SetupWithManager(t *testing.T) {
 		mockCache        = new(cache2.MockCache)
 		mockRoundTripper = new(http2.MockRoundTripper)
 		watcher          = (&v1alpha1.Watcher{
+			ObjectMeta: metav1.ObjectMeta{Name: "watcher"},
 			Spec: v1alpha1.WatcherSpec{
 				Source: v1alpha1.Source{
 					APIVersion:  "v1",
@@ -909,6 +1173,7 @@ func TestController_SetupWithManager(t *testing.T) {
 	mockManager.EXPECT().GetControllerOptions().Return(config.Controller{})
 	mockManager.EXPECT().GetScheme().Return(runtime.NewScheme())
 	mockManager.EXPECT().GetCache().Return(mockCache)
+	mockManager.EXPECT().GetAPIReader().Return(mockClient)
 	mockManager.EXPECT().GetRESTMapper().Return(meta.MultiRESTMapper{})
 	mockManager.EXPECT().GetLogger().Return(zap.New())
 	mockManager.EXPECT().GetFieldIndexer().Return(mockCache)
@@ -922,3 +1187,39 @@ func TestController_SetupWithManager(t *testing.T) {
 	// then
 	assert.Nil(t, setupErr)
 }
+
+func TestController_SetupWithManager_Sources(t *testing.T) {
+	// given
+	var (
+		mockManager = new(manager.MockManager)
+		mockCache   = new(cache2.MockCache)
+		watcher     = (&v1alpha1.Watcher{
+			ObjectMeta: metav1.ObjectMeta{Name: uuid.NewString()},
+			Spec: v1alpha1.WatcherSpec{
+				Sources: []v1alpha1.Source{
+					{APIVersion: "v1", Kind: "Secret"},
+					{APIVersion: "apps/v1", Kind: "Deployment"},
+				},
+			},
+		}).Compile()
+		controller = NewController(new(client2.MockClient), &http.Client{}, watcher)
+	)
+
+	mockManager.EXPECT().GetControllerOptions().Return(config.Controller{})
+	mockManager.EXPECT().GetScheme().Return(runtime.NewScheme())
+	mockManager.EXPECT().GetCache().Return(mockCache)
+	mockManager.EXPECT().GetAPIReader().Return(new(client2.MockClient))
+	mockManager.EXPECT().GetLogger().Return(zap.New())
+	mockManager.EXPECT().Add(mock.MatchedBy(func(ct controller2.Controller) bool {
+		return ct != nil
+	})).Return(nil).Times(2)
+
+	// when
+	setupErr := controller.SetupWithManager(mockManager)
+
+	// then
+	assert.Nil(t, setupErr)
+	mockManager.AssertNumberOfCalls(t, "Add", 2)
+	assert.Equal(t, watcher.GetName()+"-secret", controller.name())
+	assert.Equal(t, watcher.GetName()+"-deployment.apps", controller.ForSource(&watcher.Spec.Compiled.Sources[1]).name())
+}
@@ -0,0 +1,34 @@
+package pkg
+
+import (
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/nccloud/watchtower/pkg/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Diff returns the patch from the previous object of the update event to the object, and an empty string when there's
+// no previous object, like on create events.
+func (r *Controller) Diff(obj *unstructured.Unstructured) (string, error) {
+	objectEvent := r.lastEvent(client.ObjectKeyFromObject(obj))
+	if objectEvent == nil || objectEvent.OldObject == nil {
+		return "", nil
+	}
+
+	var (
+		options  = r.watcher.Spec.Destination.Diff
+		original = objectEvent.OldObject.DeepCopy().Object
+		modified = obj.DeepCopy().Object
+	)
+
+	for _, segments := range options.Compiled.IgnorePaths {
+		common.FieldPathRemove(original, segments)
+		common.FieldPathRemove(modified, segments)
+	}
+
+	if options.Format == v1alpha1.DiffFormatMergePatch {
+		return common.MergePatch(original, modified)
+	}
+
+	return common.JSONPatch(original, modified)
+}
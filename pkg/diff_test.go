@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestController_Send_Diff(t *testing.T) {
+	// given
+	var (
+		ctx    = context.Background()
+		bodies []string
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+		}))
+		newWatcher = func(format v1alpha1.DiffFormat, bodyTemplate string) *v1alpha1.Watcher {
+			return (&v1alpha1.Watcher{
+				Spec: v1alpha1.WatcherSpec{
+					Destination: v1alpha1.Destination{
+						URLTemplate:  server.URL,
+						Method:       "POST",
+						BodyTemplate: bodyTemplate,
+						Diff:         &v1alpha1.DiffOptions{Format: format},
+					},
+				},
+			}).Compile()
+		}
+		newConfigMap = func(resourceVersion, value string) *unstructured.Unstructured {
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "my-config", "namespace": "my-namespace", "resourceVersion": resourceVersion,
+				},
+				"data": map[string]interface{}{"key": value},
+			}}
+		}
+		jsonPatchController  = NewController(new(client2.MockClient), server.Client(), newWatcher("", ""))
+		mergePatchController = NewController(new(client2.MockClient), server.Client(),
+			newWatcher(v1alpha1.DiffFormatMergePatch, `{"name":"{{ .metadata.name }}","patch":{{ .Patch }}}`))
+		original = newConfigMap("1", "old")
+		modified = newConfigMap("2", "new")
+		touched  = newConfigMap("3", "new")
+	)
+	defer server.Close()
+
+	// when
+	jsonPatchController.TrackEvent().Update(event.UpdateEvent{ObjectOld: original, ObjectNew: modified})
+	jsonPatchErr := jsonPatchController.Send(ctx, modified)
+	mergePatchController.TrackEvent().Update(event.UpdateEvent{ObjectOld: original, ObjectNew: modified})
+	mergePatchErr := mergePatchController.Send(ctx, modified)
+	mergePatchController.events.Clear()
+	mergePatchController.TrackEvent().Update(event.UpdateEvent{ObjectOld: modified, ObjectNew: touched})
+	touchedErr := mergePatchController.Send(ctx, touched)
+	mergePatchController.events.Clear()
+	mergePatchController.TrackEvent().Create(event.CreateEvent{Object: touched})
+	createdErr := mergePatchController.Send(ctx, touched)
+
+	// then
+	assert.Nil(t, jsonPatchErr)
+	assert.Nil(t, mergePatchErr)
+	assert.Nil(t, touchedErr)
+	assert.Nil(t, createdErr)
+	assert.Equal(t, []string{
+		`[{"op":"replace","path":"/data/key","value":"new"}]`,
+		`{"name":"my-config","patch":{"data":{"key":"new"}}}`,
+	}, bodies)
+}
@@ -0,0 +1,281 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/nccloud/watchtower/pkg/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	BulkActionIndex  BulkAction = "index"
+	BulkActionDelete BulkAction = "delete"
+
+	bulkPath        = "/_bulk"
+	bulkContentType = "application/x-ndjson"
+	finalizerPrefix = "watchtower.cloud.spaceship.com/elasticsearch-"
+)
+
+var ErrBulkItemFailed = errors.New("bulk item failed")
+
+type BulkItemError struct {
+	Action     string
+	StatusCode int
+	Reason     string
+}
+
+type BulkAction string
+
+type BulkOperation struct {
+	Action   BulkAction
+	Index    string
+	ID       string
+	Document []byte
+}
+
+// tombstones are the deleted objects whose documents aren't deleted yet, by their uid, so the objects recreated under
+// the same name don't hide the documents of the deleted ones.
+type tombstones struct {
+	lock    sync.Mutex
+	objects map[types.NamespacedName]map[types.UID]*unstructured.Unstructured
+}
+
+type bulkResponse struct {
+	Errors bool                            `json:"errors"`
+	Items  []map[string]bulkResponseResult `json:"items"`
+}
+
+type bulkResponseResult struct {
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error"`
+}
+
+func (r *Controller) NewBulkOperation(obj *unstructured.Unstructured, action BulkAction,
+	body []byte,
+) (BulkOperation, error) {
+	index, indexErr := r.TemplateExecute(r.watcher.Spec.Destination.Elasticsearch.Compiled.IndexTemplate, obj)
+	if indexErr != nil {
+		return BulkOperation{}, indexErr
+	}
+
+	documentID, idErr := r.TemplateExecute(r.watcher.Spec.Destination.Elasticsearch.Compiled.IDTemplate, obj)
+	if idErr != nil {
+		return BulkOperation{}, idErr
+	}
+
+	operation := BulkOperation{
+		Action: action,
+		Index:  strings.TrimSpace(string(index)),
+		ID:     strings.TrimSpace(string(documentID)),
+	}
+
+	if action == BulkActionIndex {
+		var document bytes.Buffer
+		if compactErr := json.Compact(&document, body); compactErr != nil {
+			return BulkOperation{}, compactErr
+		}
+
+		operation.Document = document.Bytes()
+	}
+
+	return operation, nil
+}
+
+func (r *Controller) SendBulk(ctx context.Context, obj *unstructured.Unstructured,
+	operations []BulkOperation,
+) []error {
+	response, sendErr := r.sendBulk(ctx, obj, operations)
+	if sendErr != nil {
+		return repeatError(sendErr, len(operations))
+	}
+
+	return response.ItemErrors(len(operations))
+}
+
+func (r *Controller) sendBulk(ctx context.Context, obj *unstructured.Unstructured,
+	operations []BulkOperation,
+) (*bulkResponse, error) {
+	url, urlErr := r.TemplateExecute(r.watcher.Spec.Destination.Compiled.URLTemplate, obj)
+	if urlErr != nil {
+		return nil, urlErr
+	}
+
+	headers, headersErr := r.TemplateExecute(r.watcher.Spec.Destination.Compiled.HeaderTemplate, obj)
+	if headersErr != nil {
+		return nil, headersErr
+	}
+
+	body, bodyErr := newBulkBody(operations)
+	if bodyErr != nil {
+		return nil, bodyErr
+	}
+
+	request, requestErr := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimRight(strings.TrimSpace(string(url)), "/")+bulkPath, bytes.NewReader(body))
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	request.Header = common.StringToMap(string(headers))
+	request.Header.Set("Content-Type", bulkContentType)
+
+	doRequest, doRequestErr := r.Do(request)
+	if doRequestErr != nil {
+		return nil, doRequestErr
+	}
+
+	defer func() {
+		_ = doRequest.Body.Close()
+	}()
+
+	if doRequest.StatusCode < 200 || doRequest.StatusCode >= 300 {
+		return nil, &StatusCodeError{StatusCode: doRequest.StatusCode}
+	}
+
+	var response bulkResponse
+	if decodeErr := json.NewDecoder(doRequest.Body).Decode(&response); decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	return &response, nil
+}
+
+func (b *bulkResponse) ItemErrors(count int) []error {
+	itemErrors := make([]error, count)
+	if !b.Errors {
+		return itemErrors
+	}
+
+	for position, item := range b.Items {
+		for action, result := range item {
+			if position >= count || (result.Status >= 200 && result.Status < 300) {
+				continue
+			}
+
+			if BulkAction(action) == BulkActionDelete && result.Status == http.StatusNotFound {
+				continue
+			}
+
+			itemErrors[position] = &BulkItemError{Action: action, StatusCode: result.Status, Reason: string(result.Error)}
+		}
+	}
+
+	return itemErrors
+}
+
+func newBulkBody(operations []BulkOperation) ([]byte, error) {
+	var body bytes.Buffer
+
+	for _, operation := range operations {
+		target := map[string]string{"_index": operation.Index}
+		if operation.ID != "" {
+			target["_id"] = operation.ID
+		}
+
+		metadata, marshalErr := json.Marshal(map[BulkAction]map[string]string{operation.Action: target})
+		if marshalErr != nil {
+			return nil, marshalErr
+		}
+
+		body.Write(metadata)
+		body.WriteByte('\n')
+
+		if operation.Action == BulkActionIndex {
+			body.Write(operation.Document)
+			body.WriteByte('\n')
+		}
+	}
+
+	return body.Bytes(), nil
+}
+
+func (e *BulkItemError) Error() string {
+	return fmt.Sprintf("%s: %s with %d %s", ErrBulkItemFailed, e.Action, e.StatusCode, e.Reason)
+}
+
+func (e *BulkItemError) Unwrap() error {
+	return ErrBulkItemFailed
+}
+
+func (e *BulkItemError) statusCode() int {
+	return e.StatusCode
+}
+
+func newTombstones() *tombstones {
+	return &tombstones{objects: map[types.NamespacedName]map[types.UID]*unstructured.Unstructured{}}
+}
+
+func (t *tombstones) store(obj *unstructured.Unstructured) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	key := client.ObjectKeyFromObject(obj)
+	if t.objects[key] == nil {
+		t.objects[key] = map[types.UID]*unstructured.Unstructured{}
+	}
+
+	t.objects[key][obj.GetUID()] = obj
+}
+
+// take removes the tombstones of the key and returns the ones of the objects other than the object with the uid.
+func (t *tombstones) take(key types.NamespacedName, uid types.UID) []*unstructured.Unstructured {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	objects := make([]*unstructured.Unstructured, 0, len(t.objects[key]))
+	for objectUID, obj := range t.objects[key] {
+		if uid == "" || objectUID != uid {
+			objects = append(objects, obj)
+		}
+	}
+
+	delete(t.objects, key)
+
+	return objects
+}
+
+// finalizer is the finalizer of the watcher, which is named after the hash of its key to be short enough.
+func (r *Controller) finalizer() string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(r.watcher.Key()))
+
+	return fmt.Sprintf("%s%08x", finalizerPrefix, hash.Sum32())
+}
+
+func (r *Controller) addFinalizer(ctx context.Context, obj *unstructured.Unstructured) error {
+	if controllerutil.ContainsFinalizer(obj, r.finalizer()) {
+		return nil
+	}
+
+	patch := client.MergeFromWithOptions(obj.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	controllerutil.AddFinalizer(obj, r.finalizer())
+
+	return r.client.Patch(ctx, obj, patch)
+}
+
+// finalize deletes the document of the object being deleted, then removes the finalizer of the watcher.
+func (r *Controller) finalize(ctx context.Context, obj *unstructured.Unstructured) error {
+	if !controllerutil.ContainsFinalizer(obj, r.finalizer()) {
+		return nil
+	}
+
+	if removeErr := r.remove(ctx, obj); removeErr != nil {
+		return removeErr
+	}
+
+	patch := client.MergeFromWithOptions(obj.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	controllerutil.RemoveFinalizer(obj, r.finalizer())
+
+	return client.IgnoreNotFound(r.client.Patch(ctx, obj, patch))
+}
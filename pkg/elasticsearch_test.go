@@ -0,0 +1,251 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func newElasticsearchTestServer(response string) (*httptest.Server, *[]string) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, fmt.Sprintf("%s %s %s\n%s", r.Method, r.URL.Path,
+			r.Header.Get("Content-Type"), string(body)))
+		_, _ = w.Write([]byte(response))
+	}))
+
+	return server, &requests
+}
+
+func newElasticsearchTestWatcher(url string) *v1alpha1.Watcher {
+	return (&v1alpha1.Watcher{
+		Spec: v1alpha1.WatcherSpec{
+			Destination: v1alpha1.Destination{
+				Type:         v1alpha1.DestinationTypeElasticsearch,
+				URLTemplate:  url + "/",
+				BodyTemplate: "{\n \"name\": \"{{ .metadata.name }}\"\n}",
+				Elasticsearch: &v1alpha1.ElasticsearchDestination{
+					IndexTemplate: "secrets-{{ .metadata.namespace }}",
+				},
+			},
+		},
+	}).Compile()
+}
+
+func TestController_Reconcile_Elasticsearch(t *testing.T) {
+	// given
+	var (
+		ctx              = context.Background()
+		server, requests = newElasticsearchTestServer(`{"errors":false,"items":[{"index":{"status":201}}]}`)
+		mockClient       = new(client2.MockClient)
+		secret           = &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "my-secret", "namespace": "my-namespace", "uid": "my-uid"},
+			},
+		}
+		controller = NewController(mockClient, server.Client(), newElasticsearchTestWatcher(server.URL))
+	)
+	defer server.Close()
+	mockClient.EXPECT().Get(mock.Anything, client.ObjectKeyFromObject(secret),
+		mock.AnythingOfType("*unstructured.Unstructured")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			secret.DeepCopyInto(obj.(*unstructured.Unstructured))
+			return nil
+		})
+
+	// when
+	_, reconcileErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+
+	// then
+	assert.Nil(t, reconcileErr)
+	assert.Equal(t, []string{"POST /_bulk application/x-ndjson\n" +
+		`{"index":{"_id":"my-uid","_index":"secrets-my-namespace"}}` + "\n" +
+		`{"name":"my-secret"}` + "\n"}, *requests)
+}
+
+func TestController_Reconcile_ElasticsearchRemove(t *testing.T) {
+	// given
+	var (
+		ctx              = context.Background()
+		server, requests = newElasticsearchTestServer(
+			`{"errors":true,"items":[{"delete":{"status":404,"result":"not_found"}}]}`)
+		mockClient = new(client2.MockClient)
+		secret     = &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "my-secret", "namespace": "my-namespace", "uid": "my-uid"},
+			},
+		}
+		controller = NewController(mockClient, server.Client(), newElasticsearchTestWatcher(server.URL))
+	)
+	defer server.Close()
+	mockClient.EXPECT().Get(mock.Anything, client.ObjectKeyFromObject(secret),
+		mock.AnythingOfType("*unstructured.Unstructured")).
+		Return(errors.NewNotFound(schema.GroupResource{Resource: "secrets"}, secret.GetName()))
+
+	// when
+	deleted := controller.TrackEvent().Delete(event.DeleteEvent{Object: secret})
+	_, reconcileErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+	_, secondReconcileErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+
+	// then
+	assert.True(t, deleted)
+	assert.Nil(t, reconcileErr)
+	assert.Nil(t, secondReconcileErr)
+	assert.Equal(t, []string{"POST /_bulk application/x-ndjson\n" +
+		`{"delete":{"_id":"my-uid","_index":"secrets-my-namespace"}}` + "\n"}, *requests)
+}
+
+func TestController_Reconcile_ElasticsearchRecreated(t *testing.T) {
+	// given
+	var (
+		ctx              = context.Background()
+		server, requests = newElasticsearchTestServer(`{"errors":false,"items":[{"delete":{"status":200}}]}`)
+		mockClient       = new(client2.MockClient)
+		deleted          = &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "my-secret", "namespace": "my-namespace", "uid": "old-uid"},
+			},
+		}
+		recreated  = deleted.DeepCopy()
+		controller = NewController(mockClient, server.Client(), newElasticsearchTestWatcher(server.URL))
+	)
+	defer server.Close()
+	recreated.SetUID("new-uid")
+	mockClient.EXPECT().Get(mock.Anything, client.ObjectKeyFromObject(deleted),
+		mock.AnythingOfType("*unstructured.Unstructured")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			recreated.DeepCopyInto(obj.(*unstructured.Unstructured))
+			return nil
+		})
+
+	// when
+	controller.TrackEvent().Delete(event.DeleteEvent{Object: deleted})
+	_, reconcileErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deleted)})
+
+	// then
+	assert.Nil(t, reconcileErr)
+	assert.Len(t, *requests, 2)
+	assert.Equal(t, "POST /_bulk application/x-ndjson\n"+
+		`{"delete":{"_id":"old-uid","_index":"secrets-my-namespace"}}`+"\n", (*requests)[0])
+	assert.Contains(t, (*requests)[1], `{"index":{"_id":"new-uid","_index":"secrets-my-namespace"}}`)
+}
+
+func TestController_Reconcile_ElasticsearchFinalize(t *testing.T) {
+	// given
+	var (
+		ctx              = context.Background()
+		server, requests = newElasticsearchTestServer(`{"errors":false,"items":[{"index":{"status":201}}]}`)
+		mockClient       = new(client2.MockClient)
+		watcher          = newElasticsearchTestWatcher(server.URL)
+		secret           = &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "my-secret", "namespace": "my-namespace", "uid": "my-uid"},
+			},
+		}
+		finalizers [][]string
+	)
+	defer server.Close()
+	watcher.Spec.Destination.Elasticsearch.Finalize = true
+	controller := NewController(mockClient, server.Client(), watcher)
+	mockClient.EXPECT().Get(mock.Anything, client.ObjectKeyFromObject(secret),
+		mock.AnythingOfType("*unstructured.Unstructured")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			secret.DeepCopyInto(obj.(*unstructured.Unstructured))
+			return nil
+		})
+	mockClient.EXPECT().Patch(ctx, mock.AnythingOfType("*unstructured.Unstructured"), mock.Anything).RunAndReturn(
+		func(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			finalizers = append(finalizers, obj.GetFinalizers())
+			obj.(*unstructured.Unstructured).DeepCopyInto(secret)
+
+			return nil
+		})
+
+	// when
+	_, indexErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+
+	now := metav1.Now()
+	secret.SetDeletionTimestamp(&now)
+	_, finalizeErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+
+	// then
+	assert.Nil(t, indexErr)
+	assert.Nil(t, finalizeErr)
+	assert.Equal(t, [][]string{{controller.finalizer()}, {}}, finalizers)
+	assert.Len(t, *requests, 2)
+	assert.Contains(t, (*requests)[0], `{"index":{"_id":"my-uid","_index":"secrets-my-namespace"}}`)
+	assert.Contains(t, (*requests)[1], `{"delete":{"_id":"my-uid","_index":"secrets-my-namespace"}}`)
+	assert.False(t, controller.tracksRemovals())
+}
+
+func TestController_Reconcile_ElasticsearchPartialFailure(t *testing.T) {
+	// given
+	var (
+		ctx       = context.Background()
+		server, _ = newElasticsearchTestServer(`{"errors":true,"items":[{"index":{"status":400,` +
+			`"error":{"type":"mapper_parsing_exception"}}}]}`)
+		mockClient = new(client2.MockClient)
+		secret     = &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "my-secret", "namespace": "my-namespace"},
+			},
+		}
+		controller = NewController(mockClient, server.Client(), newElasticsearchTestWatcher(server.URL))
+	)
+	defer server.Close()
+	mockClient.EXPECT().Get(mock.Anything, client.ObjectKeyFromObject(secret),
+		mock.AnythingOfType("*unstructured.Unstructured")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			secret.DeepCopyInto(obj.(*unstructured.Unstructured))
+			return nil
+		})
+
+	// when
+	_, reconcileErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+
+	// then
+	assert.ErrorIs(t, reconcileErr, ErrBulkItemFailed)
+	assert.ErrorContains(t, reconcileErr, "mapper_parsing_exception")
+}
+
+func TestController_SendItems_ElasticsearchPerItemResults(t *testing.T) {
+	// given
+	var (
+		ctx              = context.Background()
+		server, requests = newElasticsearchTestServer(`{"errors":true,"items":[{"index":{"status":201}},` +
+			`{"index":{"status":429,"error":{"type":"es_rejected_execution_exception"}}}]}`)
+		controller = NewController(new(client2.MockClient), server.Client(), newElasticsearchTestWatcher(server.URL))
+		obj        = &unstructured.Unstructured{Object: map[string]interface{}{}}
+		items      = []*BatchItem{
+			{Object: obj, Operation: BulkOperation{Action: BulkActionIndex, Index: "a", ID: "1", Document: []byte("{}")}},
+			{Object: obj, Operation: BulkOperation{Action: BulkActionIndex, Index: "a", ID: "2", Document: []byte("{}")}},
+		}
+	)
+	defer server.Close()
+
+	// when
+	results := controller.SendItems(ctx, items)
+
+	// then
+	assert.Len(t, *requests, 1)
+	assert.Nil(t, results[0])
+	assert.ErrorIs(t, results[1], ErrBulkItemFailed)
+}
@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	EventTypeCreate  EventType = "create"
+	EventTypeUpdate  EventType = "update"
+	EventTypeDelete  EventType = "delete"
+	EventTypeGeneric EventType = "generic"
+)
+
+type EventType string
+
+type ObjectEvent struct {
+	Type EventType
+	Time time.Time
+	// FirstTime is the time of the first of the collapsed events.
+	FirstTime  time.Time
+	OldObject  *unstructured.Unstructured
+	Transition *ConditionTransition
+}
+
+func (r *Controller) TrackEvent() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(createEvent event.CreateEvent) bool {
+			r.recordEvent(createEvent.Object, nil, EventTypeCreate)
+
+			return true
+		},
+		UpdateFunc: func(updateEvent event.UpdateEvent) bool {
+			r.recordEvent(updateEvent.ObjectNew, updateEvent.ObjectOld, EventTypeUpdate)
+
+			return true
+		},
+		DeleteFunc: func(deleteEvent event.DeleteEvent) bool {
+			r.recordEvent(deleteEvent.Object, nil, EventTypeDelete)
+
+			if obj, convertErr := r.asUnstructured(deleteEvent.Object); convertErr == nil && r.tracksRemovals() {
+				r.tombstones.store(obj)
+			}
+
+			return true
+		},
+		GenericFunc: func(genericEvent event.GenericEvent) bool {
+			r.recordEvent(genericEvent.Object, nil, EventTypeGeneric)
+
+			return true
+		},
+	}
+}
+
+func (r *Controller) recordEvent(obj, oldObj client.Object, eventType EventType) {
+	now := time.Now()
+	objectEvent := &ObjectEvent{
+		Type:      eventType,
+		Time:      now,
+		FirstTime: now,
+	}
+
+	if oldObject, convertErr := r.asUnstructured(oldObj); convertErr == nil {
+		objectEvent.OldObject = oldObject
+		objectEvent.Transition = r.conditionTransition(oldObj, obj)
+	}
+
+	// The state before the first of the collapsed events and their last transition are kept while they are debounced.
+	if previous := r.lastEvent(client.ObjectKeyFromObject(obj)); previous != nil {
+		objectEvent.FirstTime = previous.FirstTime
+
+		if previous.OldObject != nil {
+			objectEvent.OldObject = previous.OldObject
+		}
+
+		if objectEvent.Transition == nil {
+			objectEvent.Transition = previous.Transition
+		}
+	}
+
+	r.events.Store(client.ObjectKeyFromObject(obj), objectEvent)
+}
+
+func (r *Controller) lastEvent(key client.ObjectKey) *ObjectEvent {
+	if objectEvent, found := r.events.Load(key); found {
+		return objectEvent.(*ObjectEvent)
+	}
+
+	return nil
+}
+
+// debounce is how long the object waits for more events, which is up to the max wait since the first of them.
+func (r *Controller) debounce(objectEvent *ObjectEvent) time.Duration {
+	eventFilter := r.watcher.Spec.Filter.Event
+	if objectEvent == nil || eventFilter.Compiled.Debounce <= 0 {
+		return 0
+	}
+
+	return min(eventFilter.Compiled.Debounce-time.Since(objectEvent.Time),
+		eventFilter.Compiled.DebounceMaxWait-time.Since(objectEvent.FirstTime))
+}
@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	http2 "github.com/nccloud/watchtower/mocks/net/http"
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestController_TrackEvent(t *testing.T) {
+	// given
+	var (
+		secret = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-secret", Namespace: "my-namespace"},
+		}
+		controller = NewController(new(client2.MockClient), &http.Client{}, (&v1alpha1.Watcher{}).Compile())
+		predicate  = controller.TrackEvent()
+	)
+
+	// when
+	created := predicate.Create(event.CreateEvent{Object: secret})
+	createdEvent := controller.lastEvent(client.ObjectKeyFromObject(secret))
+	updated := predicate.Update(event.UpdateEvent{ObjectOld: secret, ObjectNew: secret})
+	updatedEvent := controller.lastEvent(client.ObjectKeyFromObject(secret))
+	deleted := predicate.Delete(event.DeleteEvent{Object: secret})
+	deletedEvent := controller.lastEvent(client.ObjectKeyFromObject(secret))
+	generic := predicate.Generic(event.GenericEvent{Object: secret})
+	genericEvent := controller.lastEvent(client.ObjectKeyFromObject(secret))
+
+	// then
+	assert.True(t, created && updated && deleted && generic)
+	assert.Equal(t, EventTypeCreate, createdEvent.Type)
+	assert.Equal(t, EventTypeUpdate, updatedEvent.Type)
+	assert.Equal(t, EventTypeDelete, deletedEvent.Type)
+	assert.Equal(t, EventTypeGeneric, genericEvent.Type)
+	assert.Nil(t, controller.lastEvent(types.NamespacedName{Name: "unknown"}))
+}
+
+func TestController_Reconcile_Debounce(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Filter: v1alpha1.Filter{
+					Event: v1alpha1.EventFilter{
+						Debounce: ptr.To("1m"),
+					},
+				},
+				Destination: v1alpha1.Destination{
+					URLTemplate: "www.test.com",
+					Method:      "POST",
+				},
+			},
+		}).Compile()
+		mockClient       = new(client2.MockClient)
+		mockRoundTripper = new(http2.MockRoundTripper)
+		secret           = &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "my-secret", "namespace": "my-namespace"},
+			},
+		}
+		key        = client.ObjectKeyFromObject(secret)
+		controller = NewController(mockClient, &http.Client{Transport: mockRoundTripper}, watcher)
+	)
+	mockClient.EXPECT().Get(mock.Anything, key, mock.AnythingOfType("*unstructured.Unstructured")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			secret.DeepCopyInto(obj.(*unstructured.Unstructured))
+			return nil
+		})
+	mockRoundTripper.EXPECT().RoundTrip(mock.Anything).Return(&http.Response{StatusCode: 200}, nil)
+
+	// when
+	controller.TrackEvent().Update(event.UpdateEvent{ObjectOld: secret, ObjectNew: secret})
+	debouncedResult, debouncedErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	controller.events.Store(key, &ObjectEvent{Type: EventTypeUpdate, Time: time.Now().Add(-time.Minute)})
+	quietResult, quietErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	quietEvent := controller.lastEvent(key)
+	controller.TrackEvent().Update(event.UpdateEvent{ObjectOld: secret, ObjectNew: secret})
+	controller.lastEvent(key).FirstTime = time.Now().Add(-9*time.Minute - 30*time.Second)
+	cappedResult, cappedErr := controller.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+
+	// then
+	assert.Nil(t, debouncedErr)
+	assert.Greater(t, debouncedResult.RequeueAfter, 59*time.Second)
+	assert.Nil(t, quietErr)
+	assert.Zero(t, quietResult.RequeueAfter)
+	assert.Nil(t, quietEvent)
+	assert.Nil(t, cappedErr)
+	assert.LessOrEqual(t, cappedResult.RequeueAfter, 30*time.Second)
+	assert.Positive(t, cappedResult.RequeueAfter)
+	mockRoundTripper.AssertNumberOfCalls(t, "RoundTrip", 1)
+	mockClient.AssertNumberOfCalls(t, "Get", 1)
+}
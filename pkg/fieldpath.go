@@ -0,0 +1,43 @@
+package pkg
+
+import (
+	"reflect"
+
+	"github.com/nccloud/watchtower/pkg/common"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (r *Controller) fieldsChanged(oldObj, newObj client.Object) bool {
+	var (
+		update            = r.watcher.Spec.Filter.Event.Update
+		oldObject, oldErr = r.asUnstructured(oldObj)
+		newObject, newErr = r.asUnstructured(newObj)
+	)
+
+	if (len(update.FieldsChanged) == 0 && len(update.IgnoreFields) == 0) || oldErr != nil || newErr != nil {
+		return true
+	}
+
+	// The periodic re-synchronizations don't change the resource version, nor anything else.
+	if oldObj.GetResourceVersion() == newObj.GetResourceVersion() {
+		return true
+	}
+
+	oldObject, newObject = oldObject.DeepCopy(), newObject.DeepCopy()
+
+	if len(update.IgnoreFields) > 0 {
+		oldObject.SetResourceVersion("")
+		newObject.SetResourceVersion("")
+
+		for _, segments := range update.Compiled.IgnoreFields {
+			common.FieldPathRemove(oldObject.Object, segments)
+			common.FieldPathRemove(newObject.Object, segments)
+		}
+	}
+
+	if len(update.FieldsChanged) > 0 {
+		return !common.FieldPathsEqual(oldObject.Object, newObject.Object, update.Compiled.FieldsChanged)
+	}
+
+	return !reflect.DeepEqual(oldObject.Object, newObject.Object)
+}
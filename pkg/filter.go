@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/nccloud/watchtower/pkg/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type objectMatcher func(context.Context, *v1alpha1.ObjectFilter, *unstructured.Unstructured) (bool, error)
+
+func (r *Controller) MatchObject(ctx context.Context, filter *v1alpha1.ObjectFilter,
+	obj *unstructured.Unstructured,
+) (bool, error) {
+	for _, match := range []objectMatcher{
+		r.matchMetadata, r.matchLabels, r.matchNamespace, r.matchOwner, r.matchReferencedBy, r.matchCEL,
+		r.matchCustom, r.matchAllOf, r.matchAnyOf, r.matchNot,
+	} {
+		if matched, matchErr := match(ctx, filter, obj); matchErr != nil || !matched {
+			return false, matchErr
+		}
+	}
+
+	return true, nil
+}
+
+func (r *Controller) matchMetadata(_ context.Context, filter *v1alpha1.ObjectFilter,
+	obj *unstructured.Unstructured,
+) (bool, error) {
+	if filter.Name != nil && !filter.Compiled.Name.MatchString(obj.GetName()) {
+		return false, nil
+	}
+
+	return filter.Namespace == nil || filter.Compiled.Namespace.MatchString(obj.GetNamespace()), nil
+}
+
+func (r *Controller) matchLabels(_ context.Context, filter *v1alpha1.ObjectFilter,
+	obj *unstructured.Unstructured,
+) (bool, error) {
+	if filter.Labels != nil && !common.MapContains(obj.GetLabels(), *filter.Labels) {
+		return false, nil
+	}
+
+	if filter.Annotations != nil && !common.MapContains(obj.GetAnnotations(), *filter.Annotations) {
+		return false, nil
+	}
+
+	if filter.LabelSelector != nil && !filter.Compiled.LabelSelector.Matches(labels.Set(obj.GetLabels())) {
+		return false, nil
+	}
+
+	return filter.AnnotationSelector == nil ||
+		filter.Compiled.AnnotationSelector.Matches(labels.Set(obj.GetAnnotations())), nil
+}
+
+func (r *Controller) matchCEL(ctx context.Context, filter *v1alpha1.ObjectFilter,
+	obj *unstructured.Unstructured,
+) (bool, error) {
+	if filter.CEL == nil {
+		return true, nil
+	}
+
+	variables := map[string]any{
+		common.CELVariableObject:    obj.Object,
+		common.CELVariableOldObject: nil,
+		common.CELVariableEventType: "",
+	}
+
+	if objectEvent := r.lastEvent(client.ObjectKeyFromObject(obj)); objectEvent != nil {
+		variables[common.CELVariableEventType] = string(objectEvent.Type)
+
+		if objectEvent.OldObject != nil {
+			variables[common.CELVariableOldObject] = objectEvent.OldObject.Object
+		}
+	}
+
+	matched, evaluateErr := common.CELEvaluate(ctx, filter.Compiled.CEL, variables)
+	if errors.Is(evaluateErr, common.ErrCELOldObjectMissing) {
+		// The expressions reading the old object don't match the objects without one, like the created ones.
+		return false, nil
+	} else if evaluateErr != nil {
+		log.FromContext(ctx).Error(evaluateErr, "An error occurred while evaluating the CEL expression.",
+			"expression", *filter.CEL)
+	}
+
+	return matched, evaluateErr
+}
+
+func (r *Controller) matchCustom(ctx context.Context, filter *v1alpha1.ObjectFilter,
+	obj *unstructured.Unstructured,
+) (bool, error) {
+	if filter.Custom == nil {
+		return true, nil
+	}
+
+	result, executeErr := r.TemplateExecute(filter.Custom.Compiled.Template, obj)
+	if executeErr != nil {
+		return false, executeErr
+	}
+
+	return string(result) == filter.Custom.Result, nil
+}
+
+func (r *Controller) matchAllOf(ctx context.Context, filter *v1alpha1.ObjectFilter,
+	obj *unstructured.Unstructured,
+) (bool, error) {
+	for index := range filter.AllOf {
+		if matched, matchErr := r.MatchObject(ctx, &filter.AllOf[index], obj); matchErr != nil || !matched {
+			return false, matchErr
+		}
+	}
+
+	return true, nil
+}
+
+func (r *Controller) matchAnyOf(ctx context.Context, filter *v1alpha1.ObjectFilter,
+	obj *unstructured.Unstructured,
+) (bool, error) {
+	for index := range filter.AnyOf {
+		if matched, matchErr := r.MatchObject(ctx, &filter.AnyOf[index], obj); matchErr != nil || matched {
+			return matched, matchErr
+		}
+	}
+
+	return len(filter.AnyOf) == 0, nil
+}
+
+func (r *Controller) matchNot(ctx context.Context, filter *v1alpha1.ObjectFilter,
+	obj *unstructured.Unstructured,
+) (bool, error) {
+	if filter.Not == nil {
+		return true, nil
+	}
+
+	matched, matchErr := r.MatchObject(ctx, filter.Not, obj)
+
+	return !matched, matchErr
+}
@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+)
+
+func TestController_FilterObject_Composition(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Filter: v1alpha1.Filter{
+					Object: v1alpha1.ObjectFilter{
+						AnyOf: []v1alpha1.ObjectFilter{
+							{Name: ptr.To("^critical-.*$")},
+							{Labels: &map[string]string{"tier": "web"}},
+						},
+						Not: &v1alpha1.ObjectFilter{
+							AllOf: []v1alpha1.ObjectFilter{
+								{Namespace: ptr.To("^kube-.*$")},
+								{CEL: ptr.To(`object.metadata.name.endsWith("-test")`)},
+							},
+						},
+					},
+				},
+			},
+		}).Compile()
+		controller = NewController(new(client2.MockClient), &http.Client{}, watcher)
+		newObject  = func(namespace, name string, labels map[string]string) *unstructured.Unstructured {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			obj.SetNamespace(namespace)
+			obj.SetName(name)
+			obj.SetLabels(labels)
+
+			return obj
+		}
+	)
+
+	// when
+	byName, _ := controller.FilterObject(ctx, newObject("default", "critical-app", nil))
+	byLabel, _ := controller.FilterObject(ctx, newObject("default", "app", map[string]string{"tier": "web"}))
+	neither, _ := controller.FilterObject(ctx, newObject("default", "app", map[string]string{"tier": "db"}))
+	negated, _ := controller.FilterObject(ctx, newObject("kube-system", "critical-app-test", nil))
+	partiallyNegated, _ := controller.FilterObject(ctx, newObject("kube-system", "critical-app", nil))
+
+	// then
+	assert.False(t, byName)
+	assert.False(t, byLabel)
+	assert.True(t, neither)
+	assert.True(t, negated)
+	assert.False(t, partiallyNegated)
+}
+
+func TestObjectFilter_HasNamespaceSelector(t *testing.T) {
+	// given
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}
+
+	// when
+	top := (&v1alpha1.ObjectFilter{NamespaceSelector: selector}).HasNamespaceSelector()
+	nested := (&v1alpha1.ObjectFilter{
+		AnyOf: []v1alpha1.ObjectFilter{{}, {Not: &v1alpha1.ObjectFilter{NamespaceSelector: selector}}},
+	}).HasNamespaceSelector()
+	none := (&v1alpha1.ObjectFilter{AllOf: []v1alpha1.ObjectFilter{{Name: ptr.To("foo")}}}).HasNamespaceSelector()
+
+	// then
+	assert.True(t, top)
+	assert.True(t, nested)
+	assert.False(t, none)
+}
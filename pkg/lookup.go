@@ -0,0 +1,169 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/nccloud/watchtower/pkg/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// lookupTimeout bounds the reads of a lookup, since the templates are executed without the context of the
+	// reconcile.
+	lookupTimeout = 10 * time.Second
+	// lookupSyncTimeout bounds the wait for the informer of a kind to sync on its first lookup.
+	lookupSyncTimeout = 5 * time.Second
+)
+
+var ErrLookupNotAllowed = errors.New("lookup isn't allowed")
+
+// WithLookupKinds makes the objects of the kinds, like ConfigMap or Deployment.apps, readable in the templates with
+// lookup.
+func (r *Controller) WithLookupKinds(kinds []string) *Controller {
+	r.lookupKinds = make([]schema.GroupKind, 0, len(kinds))
+	for _, kind := range kinds {
+		r.lookupKinds = append(r.lookupKinds, schema.ParseGroupKind(strings.TrimSpace(kind)))
+	}
+
+	return r
+}
+
+// executeTemplate executes the template with the lookup of the watcher when it can read any kind.
+func (r *Controller) executeTemplate(tpl *template.Template, data any) ([]byte, error) {
+	if len(r.lookupKinds) == 0 {
+		return common.TemplateExecute(tpl, data)
+	}
+
+	withLookup, templateErr := r.templateWithLookup(tpl)
+	if templateErr != nil {
+		return nil, templateErr
+	}
+
+	return common.TemplateExecute(withLookup, data)
+}
+
+// templateWithLookup returns the copy of the template with the lookup of the controller, which is made once for each
+// template and shared by the executions.
+func (r *Controller) templateWithLookup(tpl *template.Template) (*template.Template, error) {
+	if withLookup, loaded := r.templates.Load(tpl); loaded {
+		return withLookup.(*template.Template), nil
+	}
+
+	withLookup, templateErr := common.TemplateWithFuncs(tpl, template.FuncMap{"lookup": r.lookupFunc})
+	if templateErr != nil {
+		return nil, templateErr
+	}
+
+	stored, _ := r.templates.LoadOrStore(tpl, withLookup)
+
+	return stored.(*template.Template), nil
+}
+
+// lookupFunc is the lookup of the templates, reading the objects within lookupTimeout.
+func (r *Controller) lookupFunc(apiVersion, kind, namespace, name string) (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	return r.lookup(ctx, apiVersion, kind, namespace, name)
+}
+
+// lookup returns the object like the lookup of Helm, the list of the objects in the namespace when the name is empty,
+// and an empty map when the object isn't found. The objects are read from the related cache of the cluster of the
+// source, as the service account of the watcher when it's set.
+func (r *Controller) lookup(ctx context.Context, apiVersion, kind, namespace, name string) (map[string]any, error) {
+	gvk := schema.FromAPIVersionAndKind(apiVersion, kind)
+	if allowedErr := r.lookupAllowed(gvk.GroupKind(), namespace, name); allowedErr != nil {
+		return nil, allowedErr
+	}
+
+	reader := r.lookupReader(ctx, gvk)
+
+	if name == "" {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(kind + "List"))
+
+		if listErr := reader.List(ctx, list, client.InNamespace(namespace)); listErr != nil {
+			return nil, listErr
+		}
+
+		return list.UnstructuredContent(), nil
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+
+	if getErr := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); getErr != nil {
+		if client.IgnoreNotFound(getErr) != nil {
+			return nil, getErr
+		}
+
+		return map[string]any{}, nil
+	}
+
+	return obj.Object, nil
+}
+
+// lookupReader returns the related cache of the cluster of the source once the informer of the kind is synced, which
+// is started on its first lookup. The kinds are read from the API server while their informers aren't synced, like the
+// ones that the service account of the watcher can't list in all namespaces, and without a related cache.
+func (r *Controller) lookupReader(ctx context.Context, gvk schema.GroupVersionKind) client.Reader {
+	relatedCache := r.clusterRelatedCache()
+	if relatedCache == nil {
+		return r.client
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+
+	informer, informerErr := relatedCache.GetInformer(ctx, obj, cache.BlockUntilSynced(false))
+	if informerErr != nil {
+		log.FromContext(ctx).Error(informerErr, "An error occurred while getting the informer of the lookup.",
+			"kind", gvk.String())
+
+		return r.client
+	}
+
+	if informer.HasSynced() {
+		return relatedCache
+	}
+
+	if _, waited := r.lookupsWaited.LoadOrStore(gvk, true); waited {
+		return r.client
+	}
+
+	syncCtx, cancel := context.WithTimeout(ctx, lookupSyncTimeout)
+	defer cancel()
+
+	if !toolscache.WaitForCacheSync(syncCtx.Done(), informer.HasSynced) {
+		return r.client
+	}
+
+	return relatedCache
+}
+
+// lookupAllowed returns an error unless the kind is allowed, and the object is in the namespace of a namespaced
+// watcher or is the namespace itself.
+func (r *Controller) lookupAllowed(groupKind schema.GroupKind, namespace, name string) error {
+	if !slices.Contains(r.lookupKinds, groupKind) {
+		return fmt.Errorf("%w: %s", ErrLookupNotAllowed, groupKind)
+	}
+
+	if !r.watcher.IsNamespaced() || namespace == r.watcher.GetNamespace() ||
+		(groupKind == schema.GroupKind{Kind: "Namespace"} && name == r.watcher.GetNamespace()) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s %s/%s is outside of namespace %s", ErrLookupNotAllowed, groupKind, namespace, name,
+		r.watcher.GetNamespace())
+}
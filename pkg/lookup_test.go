@@ -0,0 +1,208 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	cache2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/cache"
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestController_Lookup(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source:      v1alpha1.Source{APIVersion: "apps/v1", Kind: "ReplicaSet"},
+				Destination: v1alpha1.Destination{URLTemplate: "http://localhost"},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		controller = NewController(mockClient, &http.Client{}, watcher).
+				WithLookupKinds([]string{"ConfigMap", " Deployment.apps"})
+	)
+	mockClient.EXPECT().Get(ctx, types.NamespacedName{Namespace: "default", Name: "my-deployment"},
+		mock.AnythingOfType("*unstructured.Unstructured")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			assert.Equal(t, "apps/v1, Kind=Deployment", obj.GetObjectKind().GroupVersionKind().String())
+			obj.SetLabels(map[string]string{"team": "payments"})
+
+			return nil
+		})
+	mockClient.EXPECT().Get(ctx, types.NamespacedName{Namespace: "default", Name: "missing"},
+		mock.AnythingOfType("*unstructured.Unstructured")).
+		Return(apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "missing"))
+	mockClient.EXPECT().List(ctx, mock.MatchedBy(func(list *unstructured.UnstructuredList) bool {
+		return list.GetKind() == "ConfigMapList"
+	}), []client.ListOption{client.InNamespace("default")}).RunAndReturn(
+		func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+			list.(*unstructured.UnstructuredList).Items = []unstructured.Unstructured{{Object: map[string]any{
+				"metadata": map[string]any{"name": "contacts"},
+			}}}
+
+			return nil
+		})
+
+	// when
+	deployment, deploymentErr := controller.lookup(ctx, "apps/v1", "Deployment", "default", "my-deployment")
+	missing, missingErr := controller.lookup(ctx, "v1", "ConfigMap", "default", "missing")
+	list, listErr := controller.lookup(ctx, "v1", "ConfigMap", "default", "")
+	_, notAllowedErr := controller.lookup(ctx, "v1", "Secret", "default", "my-secret")
+
+	// then
+	assert.Nil(t, deploymentErr)
+	assert.Equal(t, map[string]any{"team": "payments"}, deployment["metadata"].(map[string]any)["labels"])
+	assert.Nil(t, missingErr)
+	assert.Empty(t, missing)
+	assert.Nil(t, listErr)
+	assert.Len(t, list["items"], 1)
+	assert.ErrorIs(t, notAllowedErr, ErrLookupNotAllowed)
+}
+
+func TestController_Lookup_Namespaced(t *testing.T) {
+	// given
+	var (
+		ctx               = context.Background()
+		namespacedWatcher = &v1alpha1.NamespacedWatcher{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-watcher", Namespace: "team-a"},
+			Spec: v1alpha1.WatcherSpec{
+				Source:      v1alpha1.Source{APIVersion: "v1", Kind: "Pod"},
+				Destination: v1alpha1.Destination{URLTemplate: "http://localhost"},
+			},
+		}
+		watcher    = namespacedWatcher.AsWatcher()
+		mockClient = new(client2.MockClient)
+		controller = NewController(mockClient, &http.Client{}, watcher.Compile()).
+				WithLookupKinds([]string{"ConfigMap", "Namespace"})
+	)
+	mockClient.EXPECT().Get(ctx, mock.Anything, mock.AnythingOfType("*unstructured.Unstructured")).
+		Return(nil)
+
+	// when
+	_, ownNamespaceErr := controller.lookup(ctx, "v1", "ConfigMap", "team-a", "contacts")
+	_, namespaceErr := controller.lookup(ctx, "v1", "Namespace", "", "team-a")
+	_, otherNamespaceErr := controller.lookup(ctx, "v1", "ConfigMap", "team-b", "contacts")
+	_, otherNamespaceObjectErr := controller.lookup(ctx, "v1", "Namespace", "", "team-b")
+
+	// then
+	assert.Nil(t, ownNamespaceErr)
+	assert.Nil(t, namespaceErr)
+	assert.ErrorIs(t, otherNamespaceErr, ErrLookupNotAllowed)
+	assert.ErrorIs(t, otherNamespaceObjectErr, ErrLookupNotAllowed)
+	mockClient.AssertNumberOfCalls(t, "Get", 2)
+}
+
+func TestController_TemplateExecute_Lookup(t *testing.T) {
+	// given
+	var (
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "v1", Kind: "Pod"},
+				Destination: v1alpha1.Destination{
+					URLTemplate:  "http://localhost",
+					BodyTemplate: `{{ (lookup "v1" "Namespace" "" .metadata.namespace).metadata.labels.owner }}`,
+				},
+			},
+		}).Compile()
+		mockClient  = new(client2.MockClient)
+		unavailable = NewController(mockClient, &http.Client{}, watcher)
+		controller  = NewController(mockClient, &http.Client{}, watcher).WithLookupKinds([]string{"Namespace"})
+		pod         = &unstructured.Unstructured{Object: map[string]any{
+			"metadata": map[string]any{"name": "my-pod", "namespace": "default"},
+		}}
+	)
+	mockClient.EXPECT().Get(mock.Anything, types.NamespacedName{Name: "default"},
+		mock.AnythingOfType("*unstructured.Unstructured")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			obj.SetLabels(map[string]string{"owner": "team-a"})
+
+			return nil
+		})
+
+	// when
+	_, unavailableErr := unavailable.TemplateExecute(watcher.Spec.Destination.Compiled.BodyTemplate, pod)
+	body, executeErr := controller.TemplateExecute(watcher.Spec.Destination.Compiled.BodyTemplate, pod)
+	_, reexecuteErr := controller.TemplateExecute(watcher.Spec.Destination.Compiled.BodyTemplate, pod)
+
+	// then
+	assert.ErrorContains(t, unavailableErr, "lookup isn't available")
+	assert.Nil(t, executeErr)
+	assert.Nil(t, reexecuteErr)
+	assert.Equal(t, "team-a", string(body))
+	copies := 0
+	controller.templates.Range(func(_, _ any) bool {
+		copies++
+
+		return true
+	})
+	assert.Equal(t, 1, copies)
+}
+
+// lookupInformer is the informer of a kind that's synced or never syncs.
+type lookupInformer struct {
+	cache.Informer
+
+	synced bool
+}
+
+func (i *lookupInformer) HasSynced() bool {
+	return i.synced
+}
+
+func TestController_Lookup_Cache(t *testing.T) {
+	// given
+	var (
+		ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+		watcher     = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source:      v1alpha1.Source{APIVersion: "v1", Kind: "Pod"},
+				Destination: v1alpha1.Destination{URLTemplate: "http://localhost"},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		mockCache  = new(cache2.MockCache)
+		controller = NewController(mockClient, &http.Client{}, watcher).
+				WithLookupKinds([]string{"ConfigMap", "Namespace"}).WithRelatedCache(mockCache)
+		ofKind = func(kind string) any {
+			return mock.MatchedBy(func(obj client.Object) bool {
+				return obj.GetObjectKind().GroupVersionKind().Kind == kind
+			})
+		}
+	)
+	defer cancel()
+	mockCache.EXPECT().GetInformer(mock.Anything, ofKind("ConfigMap"), mock.Anything).
+		Return(&lookupInformer{synced: true}, nil)
+	mockCache.EXPECT().GetInformer(mock.Anything, ofKind("Namespace"), mock.Anything).
+		Return(&lookupInformer{synced: false}, nil)
+	mockCache.EXPECT().Get(mock.Anything, types.NamespacedName{Namespace: "default", Name: "contacts"},
+		mock.AnythingOfType("*unstructured.Unstructured")).Return(nil)
+	mockClient.EXPECT().Get(mock.Anything, types.NamespacedName{Name: "team-a"},
+		mock.AnythingOfType("*unstructured.Unstructured")).Return(nil)
+
+	// when
+	_, cachedErr := controller.lookup(ctx, "v1", "ConfigMap", "default", "contacts")
+	_, waitedErr := controller.lookup(ctx, "v1", "Namespace", "", "team-a")
+	start := time.Now()
+	_, unsyncedErr := controller.lookup(context.Background(), "v1", "Namespace", "", "team-a")
+
+	// then
+	assert.Nil(t, cachedErr)
+	assert.Nil(t, waitedErr)
+	assert.Nil(t, unsyncedErr)
+	assert.Less(t, time.Since(start), lookupSyncTimeout)
+	mockCache.AssertNumberOfCalls(t, "Get", 1)
+	mockClient.AssertNumberOfCalls(t, "Get", 2)
+}
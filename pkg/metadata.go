@@ -0,0 +1,107 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/nccloud/watchtower/pkg/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var ErrUnexpectedObject = errors.New("unexpected object")
+
+// metadataKeys are the keys of the template data that are available when only the metadata of objects are watched.
+var metadataKeys = []string{
+	"apiVersion", "kind", "metadata", TemplateKeyTransition, TemplateKeyOldObject, TemplateKeyCluster, TemplateKeyValues,
+}
+
+// getObject reads the object, or only its metadata from the cache when it's all the source needs.
+func (r *Controller) getObject(ctx context.Context, key client.ObjectKey, obj *unstructured.Unstructured) error {
+	if !r.source.MetadataOnly || r.readsObject {
+		return r.client.Get(ctx, key, obj)
+	}
+
+	metadata := r.source.NewObject()
+	if getErr := r.client.Get(ctx, key, metadata); getErr != nil {
+		return getErr
+	}
+
+	metadataObject, convertErr := r.asUnstructured(metadata)
+	if convertErr != nil {
+		return convertErr
+	}
+
+	obj.SetUnstructuredContent(metadataObject.Object)
+
+	return nil
+}
+
+// asUnstructured returns the object as unstructured, converting the metadata of the objects of metadata only sources.
+func (r *Controller) asUnstructured(obj client.Object) (*unstructured.Unstructured, error) {
+	switch obj := obj.(type) {
+	case *unstructured.Unstructured:
+		return obj, nil
+	case *metav1.PartialObjectMetadata:
+		content, convertErr := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if convertErr != nil {
+			return nil, convertErr
+		}
+
+		metadataObject := &unstructured.Unstructured{Object: content}
+		metadataObject.SetGroupVersionKind(r.source.GroupVersionKind())
+
+		return metadataObject, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnexpectedObject, obj)
+	}
+}
+
+// readsMoreThanMetadata reports whether the filters or templates may read more than the metadata of the objects.
+func (r *Controller) readsMoreThanMetadata() bool {
+	destination := r.watcher.Spec.Destination
+
+	if destination.Diff != nil || filterReadsObject(&r.watcher.Spec.Filter.Object) {
+		return true
+	}
+
+	for _, requirement := range r.source.GetFieldSelector().Requirements() {
+		if !strings.HasPrefix(requirement.Field, "metadata.") {
+			return true
+		}
+	}
+
+	templates := []*template.Template{
+		destination.Compiled.URLTemplate, destination.Compiled.BodyTemplate, destination.Compiled.HeaderTemplate,
+	}
+	if destination.Elasticsearch != nil {
+		templates = append(templates, destination.Elasticsearch.Compiled.IndexTemplate,
+			destination.Elasticsearch.Compiled.IDTemplate)
+	}
+
+	return slices.ContainsFunc(templates, func(tpl *template.Template) bool {
+		return !common.TemplateReadsOnly(tpl, metadataKeys...)
+	})
+}
+
+func filterReadsObject(filter *v1alpha1.ObjectFilter) bool {
+	if filter.CEL != nil || (filter.Custom != nil &&
+		!common.TemplateReadsOnly(filter.Custom.Compiled.Template, metadataKeys...)) {
+		return true
+	}
+
+	if filter.Not != nil && filterReadsObject(filter.Not) {
+		return true
+	}
+
+	return slices.ContainsFunc(slices.Concat(filter.AllOf, filter.AnyOf), func(nested v1alpha1.ObjectFilter) bool {
+		return filterReadsObject(&nested)
+	})
+}
@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestController_ReadsMoreThanMetadata(t *testing.T) {
+	// given
+	newController := func(bodyTemplate string, filter v1alpha1.ObjectFilter) *Controller {
+		return NewController(new(client2.MockClient), &http.Client{}, (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source:      v1alpha1.Source{APIVersion: "v1", Kind: "Secret", MetadataOnly: true},
+				Filter:      v1alpha1.Filter{Object: filter},
+				Destination: v1alpha1.Destination{BodyTemplate: bodyTemplate},
+			},
+		}).Compile())
+	}
+
+	// when
+	metadata := newController(`{{ .kind }} {{ .metadata.name }}`, v1alpha1.ObjectFilter{
+		Not: &v1alpha1.ObjectFilter{
+			Custom: &v1alpha1.CustomObjectFilter{Template: `{{ .metadata.labels.skip }}`, Result: "true"},
+		},
+	})
+	body := newController(`{{ toJson . }}`, v1alpha1.ObjectFilter{})
+	filter := newController(`{{ .metadata.name }}`, v1alpha1.ObjectFilter{
+		AnyOf: []v1alpha1.ObjectFilter{{CEL: ptr.To(`object.type == "Opaque"`)}},
+	})
+
+	// then
+	assert.False(t, metadata.readsObject)
+	assert.True(t, body.readsObject)
+	assert.True(t, filter.readsObject)
+}
+
+func TestController_Reconcile_MetadataOnly(t *testing.T) {
+	// given
+	var (
+		body   string
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			content, _ := io.ReadAll(r.Body)
+			body = string(content)
+		}))
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "v1", Kind: "Secret", MetadataOnly: true},
+				Destination: v1alpha1.Destination{
+					URLTemplate:  server.URL,
+					Method:       "POST",
+					BodyTemplate: `{{ .kind }}/{{ .metadata.name }}/{{ .metadata.labels.team }}`,
+				},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		controller = NewController(mockClient, server.Client(), watcher)
+		key        = types.NamespacedName{Namespace: "default", Name: "my-secret"}
+	)
+	defer server.Close()
+	mockClient.EXPECT().Get(mock.Anything, key, mock.AnythingOfType("*v1.PartialObjectMetadata")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			obj.(*metav1.PartialObjectMetadata).ObjectMeta = metav1.ObjectMeta{
+				Namespace: key.Namespace, Name: key.Name, Labels: map[string]string{"team": "payments"},
+			}
+
+			return nil
+		})
+
+	// when
+	_, reconcileErr := controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+
+	// then
+	assert.Nil(t, reconcileErr)
+	assert.Equal(t, "Secret/my-secret/payments", body)
+	mockClient.AssertNotCalled(t, "Get", mock.Anything, key, mock.AnythingOfType("*unstructured.Unstructured"))
+}
@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	watcherLabel = "watcher"
+
+	circuitClosedValue   = 0
+	circuitHalfOpenValue = 1
+	circuitOpenValue     = 2
+
+	waitBucketStart  = 0.001
+	waitBucketFactor = 4
+	waitBucketCount  = 10
+)
+
+var (
+	circuitStateValues = map[CircuitState]float64{
+		CircuitStateClosed:   circuitClosedValue,
+		CircuitStateHalfOpen: circuitHalfOpenValue,
+		CircuitStateOpen:     circuitOpenValue,
+	}
+
+	RateLimitWaitSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "watchtower_rate_limit_wait_seconds",
+		Help:    "Time spent waiting for the rate limiter of the destination before sending requests.",
+		Buckets: prometheus.ExponentialBuckets(waitBucketStart, waitBucketFactor, waitBucketCount),
+	}, []string{watcherLabel})
+	RateLimitRequestsPerSecond = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "watchtower_rate_limit_requests_per_second",
+		Help: "Current requests per second limit of the destination after adaptive slowdowns.",
+	}, []string{watcherLabel})
+	CircuitBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "watchtower_circuit_breaker_state",
+		Help: "State of the circuit breaker of the destination, 0 is closed, 1 is half-open and 2 is open.",
+	}, []string{watcherLabel})
+)
+
+func init() {
+	metrics.Registry.MustRegister(RateLimitWaitSeconds, RateLimitRequestsPerSecond, CircuitBreakerState)
+}
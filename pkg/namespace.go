@@ -0,0 +1,258 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var ErrOutsideNamespace = errors.New("namespaced watchers are restricted to their own namespace")
+
+// RestrictToNamespace checks that the sources, the secrets, the config maps and the service account of a namespaced
+// watcher are in its own namespace, which the sources are watched in by default, and that it doesn't write to the
+// files of Watchtower.
+func RestrictToNamespace(watcher *v1alpha1.Watcher) error {
+	if !watcher.IsNamespaced() {
+		return nil
+	}
+
+	namespace := watcher.GetNamespace()
+
+	serviceAccountNamespace, serviceAccountName := watcher.ServiceAccount()
+	if serviceAccountName != "" && serviceAccountNamespace != namespace {
+		return fmt.Errorf("%w: service account %s", ErrOutsideNamespace, *watcher.Spec.ServiceAccountName)
+	}
+
+	for index := range watcher.Spec.ValuesFrom.Secrets {
+		if restrictErr := restrictSecret(&watcher.Spec.ValuesFrom.Secrets[index], namespace); restrictErr != nil {
+			return restrictErr
+		}
+	}
+
+	for _, configMap := range watcher.Spec.ValuesFrom.ConfigMaps {
+		if configMap.Namespace != namespace {
+			return fmt.Errorf("%w: config map %s/%s", ErrOutsideNamespace, configMap.Namespace, configMap.Name)
+		}
+	}
+
+	if watcher.Spec.Destination.Type == v1alpha1.DestinationTypeFile {
+		return fmt.Errorf("%w: file destination", ErrOutsideNamespace)
+	}
+
+	return restrictSources(&watcher.Spec, namespace)
+}
+
+func restrictSources(spec *v1alpha1.WatcherSpec, namespace string) error {
+	if spec.Source.Kind != "" {
+		if restrictErr := restrictSource(&spec.Source, namespace); restrictErr != nil {
+			return restrictErr
+		}
+	}
+
+	for index := range spec.Sources {
+		if restrictErr := restrictSource(&spec.Sources[index], namespace); restrictErr != nil {
+			return restrictErr
+		}
+	}
+
+	return nil
+}
+
+func restrictSource(source *v1alpha1.Source, namespace string) error {
+	if len(source.Namespaces) == 0 {
+		source.Namespaces = []string{namespace}
+	}
+
+	for _, sourceNamespace := range source.Namespaces {
+		if sourceNamespace != namespace {
+			return fmt.Errorf("%w: source %s/%s in %s", ErrOutsideNamespace, source.APIVersion, source.Kind,
+				sourceNamespace)
+		}
+	}
+
+	if source.Cluster != nil {
+		return restrictSecret(&source.Cluster.KubeConfig, namespace)
+	}
+
+	return nil
+}
+
+func restrictSecret(selector *v1alpha1.SecretKeySelector, namespace string) error {
+	if selector.Namespace != namespace {
+		return fmt.Errorf("%w: secret %s", ErrOutsideNamespace, selector.String())
+	}
+
+	return nil
+}
+
+func (r *Controller) matchNamespace(ctx context.Context, filter *v1alpha1.ObjectFilter,
+	obj *unstructured.Unstructured,
+) (bool, error) {
+	if filter.NamespaceSelector == nil {
+		return true, nil
+	}
+
+	if obj.GetNamespace() == "" {
+		return false, nil
+	}
+
+	var namespace v1.Namespace
+	if getErr := r.namespaceReader().Get(ctx, client.ObjectKey{Name: obj.GetNamespace()},
+		&namespace); getErr != nil {
+		return false, client.IgnoreNotFound(getErr)
+	}
+
+	return filter.Compiled.NamespaceSelector.Matches(labels.Set(namespace.GetLabels())), nil
+}
+
+// namespaceReader reads the namespaces from the API server as the service account of the watcher, which may be
+// allowed to get them without listing them, so that an informer of the namespaces would never sync.
+func (r *Controller) namespaceReader() client.Reader {
+	if r.apiReader != nil {
+		return r.apiReader
+	}
+
+	return r.client
+}
+
+// NamespaceRequests returns the requests of the objects of the namespace, which are listed from the cache of the
+// source with the list type of its informer.
+func (r *Controller) NamespaceRequests(reader client.Reader) handler.MapFunc {
+	return func(ctx context.Context, namespace client.Object) []reconcile.Request {
+		list := r.source.NewObjectList()
+		if listErr := reader.List(ctx, list, client.InNamespace(namespace.GetName())); listErr != nil {
+			log.FromContext(ctx).Error(listErr, "An error occurred while listing the objects of the namespace.",
+				"namespace", namespace.GetName())
+
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, meta.LenList(list))
+		_ = meta.EachListItem(list, func(item runtime.Object) error {
+			if itemMeta, metaErr := meta.Accessor(item); metaErr == nil {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Name: itemMeta.GetName(), Namespace: itemMeta.GetNamespace(),
+				}})
+			}
+
+			return nil
+		})
+
+		return requests
+	}
+}
+
+// namespacesSource watches the namespaces only when the service account of the watcher is allowed to list and
+// watch them, since their informer would never sync and stop the controller otherwise. The objects of the namespaces
+// whose labels are changed are matched again on the next resync then.
+type namespacesSource struct {
+	source.SyncingSource
+
+	controller *Controller
+	started    atomic.Bool
+}
+
+func (s *namespacesSource) Start(ctx context.Context,
+	queue workqueue.TypedRateLimitingInterface[reconcile.Request],
+) error {
+	watchable, reviewErr := s.controller.namespacesWatchable(ctx)
+	if reviewErr != nil {
+		log.FromContext(ctx).Error(reviewErr, "An error occurred while reviewing the access to the namespaces.",
+			"watcher", s.controller.name())
+	}
+
+	if !watchable {
+		log.FromContext(ctx).Info("Not watching the namespaces, the service account of the watcher isn't allowed "+
+			"to list and watch them.", "watcher", s.controller.name())
+
+		return nil
+	}
+
+	s.started.Store(true)
+
+	return s.SyncingSource.Start(ctx, queue)
+}
+
+func (s *namespacesSource) WaitForSync(ctx context.Context) error {
+	if !s.started.Load() {
+		return nil
+	}
+
+	return s.SyncingSource.WaitForSync(ctx)
+}
+
+// namespacesWatchable returns whether the client of the source, which impersonates the service account of the
+// watcher, is allowed to list and watch the namespaces.
+func (r *Controller) namespacesWatchable(ctx context.Context) (bool, error) {
+	for _, verb := range []string{"list", "watch"} {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: verb, Resource: "namespaces"},
+			},
+		}
+		if createErr := r.client.Create(ctx, review); createErr != nil || !review.Status.Allowed {
+			return false, createErr
+		}
+	}
+
+	return true, nil
+}
+
+// NamespaceSelected passes the updates of the namespaces whose labels start matching a namespace selector of the
+// filter, or stop matching a negated one, so that the objects of the namespace can start matching the filter.
+func NamespaceSelected(filter *v1alpha1.ObjectFilter) predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(updateEvent event.UpdateEvent) bool {
+			return namespaceSelected(filter, false, updateEvent.ObjectOld.GetLabels(),
+				updateEvent.ObjectNew.GetLabels())
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+func namespaceSelected(filter *v1alpha1.ObjectFilter, negated bool, oldLabels, newLabels labels.Set) bool {
+	if selector := filter.Compiled.NamespaceSelector; selector != nil &&
+		selector.Matches(oldLabels) == negated && selector.Matches(newLabels) != negated {
+		return true
+	}
+
+	if filter.Not != nil && namespaceSelected(filter.Not, !negated, oldLabels, newLabels) {
+		return true
+	}
+
+	for _, filters := range [][]v1alpha1.ObjectFilter{filter.AllOf, filter.AnyOf} {
+		for index := range filters {
+			if namespaceSelected(&filters[index], negated, oldLabels, newLabels) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,385 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	cache2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/cache"
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+func TestRestrictToNamespace(t *testing.T) {
+	kubeConfig := func(namespace string) *v1alpha1.ClusterSource {
+		return &v1alpha1.ClusterSource{
+			KubeConfig: v1alpha1.SecretKeySelector{Name: "kubeconfig", Namespace: namespace, Key: "config"},
+		}
+	}
+
+	for name, testCase := range map[string]struct {
+		namespace  string
+		spec       v1alpha1.WatcherSpec
+		namespaces []string
+		restricted bool
+	}{
+		"cluster watcher": {
+			spec: v1alpha1.WatcherSpec{Source: v1alpha1.Source{APIVersion: "v1", Kind: "Secret"}},
+		},
+		"own namespace by default": {
+			namespace:  "team-a",
+			spec:       v1alpha1.WatcherSpec{Source: v1alpha1.Source{APIVersion: "v1", Kind: "Secret"}},
+			namespaces: []string{"team-a"},
+		},
+		"source in other namespace": {
+			namespace: "team-a",
+			spec: v1alpha1.WatcherSpec{Sources: []v1alpha1.Source{
+				{APIVersion: "v1", Kind: "Secret", Namespaces: []string{"team-a", "team-b"}},
+			}},
+			restricted: true,
+		},
+		"kubeconfig in other namespace": {
+			namespace: "team-a",
+			spec: v1alpha1.WatcherSpec{Source: v1alpha1.Source{
+				APIVersion: "v1", Kind: "Secret", Cluster: kubeConfig("team-b"),
+			}},
+			restricted: true,
+		},
+		"service account in other namespace": {
+			namespace: "team-a",
+			spec: v1alpha1.WatcherSpec{
+				Source:             v1alpha1.Source{APIVersion: "v1", Kind: "Secret"},
+				ServiceAccountName: ptr.To("team-b/reader"),
+			},
+			restricted: true,
+		},
+		"service account in own namespace": {
+			namespace: "team-a",
+			spec: v1alpha1.WatcherSpec{
+				Source:             v1alpha1.Source{APIVersion: "v1", Kind: "Secret"},
+				ServiceAccountName: ptr.To("reader"),
+			},
+			namespaces: []string{"team-a"},
+		},
+		"config map in other namespace": {
+			namespace: "team-a",
+			spec: v1alpha1.WatcherSpec{ValuesFrom: v1alpha1.ValuesFrom{ConfigMaps: []v1alpha1.ConfigMapSelector{
+				{Name: "values", Namespace: "team-b"},
+			}}},
+			restricted: true,
+		},
+		"file destination": {
+			namespace: "team-a",
+			spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "v1", Kind: "Secret"},
+				Destination: v1alpha1.Destination{
+					Type: v1alpha1.DestinationTypeFile, File: &v1alpha1.FileDestination{Path: "/var/log/watchtower.log"},
+				},
+			},
+			restricted: true,
+		},
+		"values from other namespace": {
+			namespace: "team-a",
+			spec: v1alpha1.WatcherSpec{ValuesFrom: v1alpha1.ValuesFrom{Secrets: []v1alpha1.SecretKeySelector{
+				{Name: "values", Namespace: "team-b", Key: "spec"},
+			}}},
+			restricted: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// given
+			watcher := &v1alpha1.Watcher{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-watcher", Namespace: testCase.namespace},
+				Spec:       testCase.spec,
+			}
+
+			// when
+			restrictErr := RestrictToNamespace(watcher)
+
+			// then
+			if testCase.restricted {
+				assert.ErrorIs(t, restrictErr, ErrOutsideNamespace)
+
+				return
+			}
+
+			assert.Nil(t, restrictErr)
+			assert.Equal(t, testCase.namespaces, watcher.Spec.Source.Namespaces)
+		})
+	}
+}
+
+func TestController_SetCondition_Namespaced(t *testing.T) {
+	// given
+	var (
+		mockClient            = new(client2.MockClient)
+		mockSubResourceClient = new(client2.MockSubResourceClient)
+		namespacedWatcher     = &v1alpha1.NamespacedWatcher{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-watcher", Namespace: "team-a"},
+			Spec: v1alpha1.WatcherSpec{
+				Sources: []v1alpha1.Source{{APIVersion: "v1", Kind: "Secret"}, {APIVersion: "v1", Kind: "ConfigMap"}},
+			},
+		}
+		watcher    = namespacedWatcher.AsWatcher()
+		controller = NewController(mockClient, &http.Client{}, watcher.Compile())
+	)
+	mockClient.EXPECT().Status().Return(mockSubResourceClient)
+	mockSubResourceClient.EXPECT().Patch(mock.Anything, mock.MatchedBy(func(obj client.Object) bool {
+		return obj.GetObjectKind().GroupVersionKind().Kind == v1alpha1.KindNamespacedWatcher &&
+			obj.GetNamespace() == "team-a" && obj.GetName() == "my-watcher"
+	}), client.Apply, mock.Anything, mock.Anything).Return(nil)
+
+	// when
+	setErr := controller.SetCondition(context.Background(), metav1.Condition{
+		Type: v1alpha1.ConditionTypeSourcesAvailable, Status: metav1.ConditionTrue, Reason: "KindsServed",
+	})
+
+	// then
+	assert.Nil(t, setErr)
+	assert.Equal(t, "team-a/my-watcher", watcher.Key())
+	assert.Equal(t, "team-a/my-watcher-secret", controller.name())
+}
+
+func TestSetLoaded(t *testing.T) {
+	// given
+	var (
+		ctx                   = context.Background()
+		mockClient            = new(client2.MockClient)
+		mockSubResourceClient = new(client2.MockSubResourceClient)
+		namespacedWatcher     = &v1alpha1.NamespacedWatcher{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-watcher", Namespace: "team-a", Generation: 2},
+		}
+		watcher         = namespacedWatcher.AsWatcher()
+		reportedWatcher = namespacedWatcher.AsWatcher()
+	)
+	reportedWatcher.Status.Conditions = []metav1.Condition{{
+		Type: v1alpha1.ConditionTypeLoaded, Status: metav1.ConditionTrue, ObservedGeneration: 2,
+		Message: "The watcher is loaded.",
+	}}
+	mockClient.EXPECT().Status().Return(mockSubResourceClient)
+	mockSubResourceClient.EXPECT().Patch(mock.Anything, mock.MatchedBy(func(obj client.Object) bool {
+		conditions, _, _ := unstructured.NestedSlice(obj.(*unstructured.Unstructured).Object, "status", "conditions")
+
+		return obj.GetObjectKind().GroupVersionKind().Kind == v1alpha1.KindNamespacedWatcher &&
+			conditions[0].(map[string]any)["status"] == string(metav1.ConditionFalse)
+	}), client.Apply, mock.Anything, mock.Anything).Return(nil)
+
+	// when
+	failedErr := SetLoaded(ctx, mockClient, &watcher, ErrOutsideNamespace)
+	reportedErr := SetLoaded(ctx, mockClient, &reportedWatcher, nil)
+
+	// then
+	assert.Nil(t, failedErr)
+	assert.Nil(t, reportedErr)
+	mockSubResourceClient.AssertNumberOfCalls(t, "Patch", 1)
+}
+
+func TestController_FilterObject_NamespaceSelector(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Filter: v1alpha1.Filter{
+					Object: v1alpha1.ObjectFilter{
+						NamespaceSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"team": "payments"},
+						},
+					},
+				},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		controller = NewController(mockClient, &http.Client{}, watcher)
+		newObject  = func(namespace string) *unstructured.Unstructured {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			obj.SetNamespace(namespace)
+
+			return obj
+		}
+		getNamespace = func(namespaceLabels map[string]string) func(context.Context, types.NamespacedName,
+			client.Object, ...client.GetOption) error {
+			return func(ctx context.Context, key types.NamespacedName, obj client.Object,
+				opts ...client.GetOption,
+			) error {
+				obj.SetLabels(namespaceLabels)
+
+				return nil
+			}
+		}
+	)
+	mockClient.EXPECT().Get(mock.Anything, types.NamespacedName{Name: "payments"},
+		mock.AnythingOfType("*v1.Namespace")).RunAndReturn(getNamespace(map[string]string{"team": "payments"}))
+	mockClient.EXPECT().Get(mock.Anything, types.NamespacedName{Name: "billing"},
+		mock.AnythingOfType("*v1.Namespace")).RunAndReturn(getNamespace(map[string]string{"team": "billing"}))
+
+	// when
+	payments, paymentsErr := controller.FilterObject(ctx, newObject("payments"))
+	billing, billingErr := controller.FilterObject(ctx, newObject("billing"))
+	clusterScoped, _ := controller.FilterObject(ctx, newObject(""))
+
+	// then
+	assert.Nil(t, paymentsErr)
+	assert.Nil(t, billingErr)
+	assert.False(t, payments)
+	assert.True(t, billing)
+	assert.True(t, clusterScoped)
+}
+
+func TestController_NamespaceRequests(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "v1", Kind: "Secret"},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		controller = NewController(mockClient, &http.Client{}, watcher)
+		namespace  = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments"}}
+	)
+	mockClient.EXPECT().List(mock.Anything, mock.MatchedBy(func(list *unstructured.UnstructuredList) bool {
+		return list.GetKind() == "SecretList"
+	}), []client.ListOption{client.InNamespace("payments")}).RunAndReturn(
+		func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+			for _, name := range []string{"first", "second"} {
+				item := unstructured.Unstructured{Object: map[string]interface{}{}}
+				item.SetName(name)
+				item.SetNamespace("payments")
+				list.(*unstructured.UnstructuredList).Items = append(list.(*unstructured.UnstructuredList).Items, item)
+			}
+
+			return nil
+		})
+
+	// when
+	requests := controller.NamespaceRequests(mockClient)(ctx, namespace)
+
+	// then
+	assert.Len(t, requests, 2)
+	assert.Equal(t, types.NamespacedName{Name: "first", Namespace: "payments"}, requests[0].NamespacedName)
+	assert.Equal(t, types.NamespacedName{Name: "second", Namespace: "payments"}, requests[1].NamespacedName)
+}
+
+func TestController_NamespaceRequests_MetadataOnly(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "v1", Kind: "Secret", MetadataOnly: true},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		controller = NewController(mockClient, &http.Client{}, watcher)
+		namespace  = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments"}}
+	)
+	mockClient.EXPECT().List(mock.Anything, mock.MatchedBy(func(list *metav1.PartialObjectMetadataList) bool {
+		return list.GetObjectKind().GroupVersionKind().Kind == "SecretList"
+	}), []client.ListOption{client.InNamespace("payments")}).RunAndReturn(
+		func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+			list.(*metav1.PartialObjectMetadataList).Items = []metav1.PartialObjectMetadata{
+				{ObjectMeta: metav1.ObjectMeta{Name: "first", Namespace: "payments"}},
+			}
+
+			return nil
+		})
+
+	// when
+	requests := controller.NamespaceRequests(mockClient)(ctx, namespace)
+
+	// then
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Name: "first", Namespace: "payments",
+	}}}, requests)
+}
+
+func TestNamespacesSource(t *testing.T) {
+	for name, allowed := range map[string]bool{"allowed": true, "denied": false} {
+		t.Run(name, func(t *testing.T) {
+			// given
+			var (
+				ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+				mockClient  = new(client2.MockClient)
+				mockCache   = new(cache2.MockCache)
+				controller  = NewController(mockClient, &http.Client{}, (&v1alpha1.Watcher{}).Compile())
+				namespaces  = &namespacesSource{
+					SyncingSource: source.Kind(mockCache, client.Object(&v1.Namespace{}),
+						&handler.EnqueueRequestForObject{}),
+					controller: controller,
+				}
+				queue = workqueue.NewTypedRateLimitingQueue(
+					workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+			)
+			defer cancel()
+			mockClient.EXPECT().Create(mock.Anything, mock.AnythingOfType("*v1.SelfSubjectAccessReview")).RunAndReturn(
+				func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+					obj.(*authorizationv1.SelfSubjectAccessReview).Status.Allowed = allowed
+
+					return nil
+				})
+			mockCache.EXPECT().GetInformer(mock.Anything, mock.Anything).Return(nil, errors.New("not synced")).Maybe()
+
+			// when
+			startErr := namespaces.Start(ctx, queue)
+			syncErr := namespaces.WaitForSync(ctx)
+
+			// then
+			assert.Nil(t, startErr)
+			assert.Equal(t, allowed, syncErr != nil)
+			assert.Equal(t, allowed, namespaces.started.Load())
+		})
+	}
+}
+
+func TestNamespaceSelected(t *testing.T) {
+	// given
+	var (
+		selector     = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}
+		filter       = &v1alpha1.ObjectFilter{AnyOf: []v1alpha1.ObjectFilter{{NamespaceSelector: selector}}}
+		negated      = &v1alpha1.ObjectFilter{Not: &v1alpha1.ObjectFilter{NamespaceSelector: selector}}
+		newNamespace = func(labels map[string]string) *v1.Namespace {
+			return &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: labels}}
+		}
+		unlabeled = newNamespace(nil)
+		labeled   = newNamespace(map[string]string{"team": "payments"})
+		relabeled = newNamespace(map[string]string{"team": "payments", "env": "prod"})
+	)
+	filter.Compile()
+	negated.Compile()
+
+	// when
+	created := NamespaceSelected(filter).Create(event.CreateEvent{Object: labeled})
+	selected := NamespaceSelected(filter).Update(event.UpdateEvent{ObjectOld: unlabeled, ObjectNew: labeled})
+	stillSelected := NamespaceSelected(filter).Update(event.UpdateEvent{ObjectOld: labeled, ObjectNew: relabeled})
+	unselected := NamespaceSelected(filter).Update(event.UpdateEvent{ObjectOld: labeled, ObjectNew: unlabeled})
+	negatedUnselected := NamespaceSelected(negated).Update(event.UpdateEvent{ObjectOld: labeled, ObjectNew: unlabeled})
+	negatedSelected := NamespaceSelected(negated).Update(event.UpdateEvent{ObjectOld: unlabeled, ObjectNew: labeled})
+	deleted := NamespaceSelected(filter).Delete(event.DeleteEvent{Object: labeled})
+
+	// then
+	assert.False(t, created)
+	assert.True(t, selected)
+	assert.False(t, stillSelected)
+	assert.False(t, unselected)
+	assert.True(t, negatedUnselected)
+	assert.False(t, negatedSelected)
+	assert.False(t, deleted)
+}
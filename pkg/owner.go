@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"context"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	maxOwnerDepth = 10
+)
+
+func (r *Controller) matchOwner(ctx context.Context, filter *v1alpha1.ObjectFilter,
+	obj *unstructured.Unstructured,
+) (bool, error) {
+	if filter.Owner == nil {
+		return true, nil
+	}
+
+	return r.ownedBy(ctx, filter.Owner, obj, 0)
+}
+
+// ownedBy returns whether the owner references of the object match the filter. The metadata of the owners are read
+// from the related cache when they're matched recursively.
+func (r *Controller) ownedBy(ctx context.Context, filter *v1alpha1.OwnerFilter, obj client.Object,
+	depth int,
+) (bool, error) {
+	for _, reference := range obj.GetOwnerReferences() {
+		if filter.Matches(reference) {
+			return true, nil
+		}
+
+		if !filter.Recursive || depth >= maxOwnerDepth ||
+			(filter.Controller && (reference.Controller == nil || !*reference.Controller)) {
+			continue
+		}
+
+		owner := &metav1.PartialObjectMetadata{}
+		owner.SetGroupVersionKind(schema.FromAPIVersionAndKind(reference.APIVersion, reference.Kind))
+		owner.SetNamespace(obj.GetNamespace())
+
+		if getErr := r.objectReader().Get(ctx, client.ObjectKey{
+			Namespace: obj.GetNamespace(), Name: reference.Name,
+		}, owner); getErr != nil {
+			if client.IgnoreNotFound(getErr) != nil {
+				return false, getErr
+			}
+
+			continue
+		}
+
+		if owned, ownedErr := r.ownedBy(ctx, filter, owner, depth+1); ownedErr != nil || owned {
+			return owned, ownedErr
+		}
+	}
+
+	return false, nil
+}
+
+// matchReferencedBy returns whether an object of the filter in the namespace of the object references it, which are
+// listed from the related cache.
+func (r *Controller) matchReferencedBy(ctx context.Context, filter *v1alpha1.ObjectFilter,
+	obj *unstructured.Unstructured,
+) (bool, error) {
+	if filter.ReferencedBy == nil {
+		return true, nil
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(filter.ReferencedBy.APIVersion)
+	list.SetKind(filter.ReferencedBy.Kind + "List")
+
+	if listErr := r.objectReader().List(ctx, list, client.InNamespace(obj.GetNamespace())); listErr != nil {
+		return false, listErr
+	}
+
+	for index := range list.Items {
+		if filter.ReferencedBy.Matches(&list.Items[index], obj.GetName()) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// objectReader reads the related objects of the filters from the related cache once the source is set up.
+func (r *Controller) objectReader() client.Reader {
+	if r.relatedReader != nil {
+		return r.relatedReader
+	}
+
+	return r.client
+}
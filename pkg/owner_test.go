@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestController_FilterObject_Owner(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Filter: v1alpha1.Filter{
+					Object: v1alpha1.ObjectFilter{
+						Owner: &v1alpha1.OwnerFilter{
+							Kind:       "ReplicaSet",
+							Name:       ptr.To("^web-.*"),
+							Controller: true,
+						},
+					},
+				},
+			},
+		}).Compile()
+		controller = NewController(new(client2.MockClient), &http.Client{}, watcher)
+		newPod     = func(references ...metav1.OwnerReference) *unstructured.Unstructured {
+			pod := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			pod.SetOwnerReferences(references)
+
+			return pod
+		}
+	)
+
+	// when
+	owned, ownedErr := controller.FilterObject(ctx, newPod(metav1.OwnerReference{
+		Kind: "ReplicaSet", Name: "web-7c9f", Controller: ptr.To(true),
+	}))
+	notController, _ := controller.FilterObject(ctx, newPod(metav1.OwnerReference{
+		Kind: "ReplicaSet", Name: "web-7c9f",
+	}))
+	otherName, _ := controller.FilterObject(ctx, newPod(metav1.OwnerReference{
+		Kind: "ReplicaSet", Name: "api-5d8b", Controller: ptr.To(true),
+	}))
+	orphan, _ := controller.FilterObject(ctx, newPod())
+
+	// then
+	assert.Nil(t, ownedErr)
+	assert.False(t, owned)
+	assert.True(t, notController)
+	assert.True(t, otherName)
+	assert.True(t, orphan)
+}
+
+func TestController_FilterObject_OwnerRecursive(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Filter: v1alpha1.Filter{
+					Object: v1alpha1.ObjectFilter{
+						Owner: &v1alpha1.OwnerFilter{
+							Kind:      "CronJob.batch",
+							Recursive: true,
+						},
+					},
+				},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		controller = NewController(mockClient, &http.Client{}, watcher)
+		newPod     = func(jobName string) *unstructured.Unstructured {
+			pod := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			pod.SetNamespace("my-namespace")
+			pod.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: jobName}})
+
+			return pod
+		}
+	)
+	mockClient.EXPECT().Get(mock.Anything, types.NamespacedName{Namespace: "my-namespace", Name: "scheduled-job"},
+		mock.AnythingOfType("*v1.PartialObjectMetadata")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			assert.Equal(t, "batch/v1, Kind=Job", obj.GetObjectKind().GroupVersionKind().String())
+			obj.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "CronJob", Name: "schedule"}})
+
+			return nil
+		})
+	mockClient.EXPECT().Get(mock.Anything, types.NamespacedName{Namespace: "my-namespace", Name: "manual-job"},
+		mock.AnythingOfType("*v1.PartialObjectMetadata")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			obj.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "CronJob", Name: "other"}})
+
+			return nil
+		})
+	mockClient.EXPECT().Get(mock.Anything, types.NamespacedName{Namespace: "my-namespace", Name: "other"},
+		mock.AnythingOfType("*v1.PartialObjectMetadata")).Return(nil)
+	mockClient.EXPECT().Get(mock.Anything, types.NamespacedName{Namespace: "my-namespace", Name: "deleted-job"},
+		mock.AnythingOfType("*v1.PartialObjectMetadata")).Return(
+		apierrors.NewNotFound(schema.GroupResource{Group: "batch", Resource: "jobs"}, "deleted-job"))
+
+	// when
+	scheduled, scheduledErr := controller.FilterObject(ctx, newPod("scheduled-job"))
+	manual, manualErr := controller.FilterObject(ctx, newPod("manual-job"))
+	deleted, deletedErr := controller.FilterObject(ctx, newPod("deleted-job"))
+
+	// then
+	assert.Nil(t, scheduledErr)
+	assert.Nil(t, manualErr)
+	assert.Nil(t, deletedErr)
+	assert.False(t, scheduled)
+	assert.True(t, manual)
+	assert.True(t, deleted)
+}
+
+func TestController_FilterObject_ReferencedBy(t *testing.T) {
+	// given
+	var (
+		ctx     = context.Background()
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Filter: v1alpha1.Filter{
+					Object: v1alpha1.ObjectFilter{
+						ReferencedBy: &v1alpha1.ReferenceFilter{
+							APIVersion: "v1",
+							Kind:       "ServiceAccount",
+							Name:       ptr.To("^builder$"),
+							FieldPaths: []string{"secrets[*].name", "imagePullSecrets[*].name"},
+						},
+					},
+				},
+			},
+		}).Compile()
+		mockClient = new(client2.MockClient)
+		controller = NewController(mockClient, &http.Client{}, watcher)
+		newSecret  = func(name string) *unstructured.Unstructured {
+			secret := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			secret.SetNamespace("my-namespace")
+			secret.SetName(name)
+
+			return secret
+		}
+	)
+	mockClient.EXPECT().List(mock.Anything, mock.MatchedBy(func(list *unstructured.UnstructuredList) bool {
+		return list.GetKind() == "ServiceAccountList"
+	}), []client.ListOption{client.InNamespace("my-namespace")}).RunAndReturn(
+		func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+			list.(*unstructured.UnstructuredList).Items = []unstructured.Unstructured{
+				{Object: map[string]any{
+					"metadata":         map[string]any{"name": "builder"},
+					"secrets":          []any{map[string]any{"name": "builder-token"}},
+					"imagePullSecrets": []any{map[string]any{"name": "registry"}},
+				}},
+				{Object: map[string]any{
+					"metadata": map[string]any{"name": "default"},
+					"secrets":  []any{map[string]any{"name": "default-token"}},
+				}},
+			}
+
+			return nil
+		})
+
+	// when
+	token, tokenErr := controller.FilterObject(ctx, newSecret("builder-token"))
+	registry, _ := controller.FilterObject(ctx, newSecret("registry"))
+	otherAccount, _ := controller.FilterObject(ctx, newSecret("default-token"))
+	unreferenced, _ := controller.FilterObject(ctx, newSecret("unreferenced"))
+
+	// then
+	assert.Nil(t, tokenErr)
+	assert.False(t, token)
+	assert.False(t, registry)
+	assert.True(t, otherAccount)
+	assert.True(t, unreferenced)
+}
@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"golang.org/x/time/rate"
+)
+
+const (
+	rateLimitSlowdownFactor = 0.5
+	rateLimitRecoveryFactor = 1.1
+	rateLimitMinimumDivisor = 32
+)
+
+type RateLimiter struct {
+	limiter *rate.Limiter
+	limit   rate.Limit
+	mutex   sync.Mutex
+}
+
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), burst),
+		limit:   rate.Limit(requestsPerSecond),
+	}
+}
+
+func (l *RateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	waitErr := l.limiter.Wait(ctx)
+
+	return time.Since(start), waitErr
+}
+
+func (l *RateLimiter) Observe(statusCode int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	current := l.limiter.Limit()
+
+	if statusCode == http.StatusTooManyRequests {
+		l.limiter.SetLimit(max(current*rateLimitSlowdownFactor, l.limit/rateLimitMinimumDivisor))
+
+		return
+	}
+
+	if current < l.limit {
+		l.limiter.SetLimit(min(current*rateLimitRecoveryFactor, l.limit))
+	}
+}
+
+func (l *RateLimiter) Limit() float64 {
+	return float64(l.limiter.Limit())
+}
+
+// RateLimiters are the rate limiters of the hosts shared by the watchers of a manager, so that they're built again
+// with the latest options when the manager is restarted. The watchers with different options for the same host don't
+// share their rate limiters.
+type RateLimiters struct {
+	limiters sync.Map
+}
+
+func NewRateLimiters() *RateLimiters {
+	return &RateLimiters{}
+}
+
+func (l *RateLimiters) forHost(host string, options *v1alpha1.RateLimitOptions) *RateLimiter {
+	key := fmt.Sprintf("%s/%g/%d", host, options.Compiled.RequestsPerSecond, options.GetBurst())
+	limiter, _ := l.limiters.LoadOrStore(key, NewRateLimiter(options.Compiled.RequestsPerSecond, options.GetBurst()))
+
+	return limiter.(*RateLimiter)
+}
+
+// WithRateLimiters makes the shared rate limits of the watcher shared with the watchers using the same rate limiters.
+func (r *Controller) WithRateLimiters(limiters *RateLimiters) *Controller {
+	r.rateLimiters = limiters
+
+	return r
+}
+
+func (r *Controller) rateLimiter(host string) *RateLimiter {
+	options := r.watcher.Spec.Destination.RateLimit
+	if options == nil {
+		return nil
+	}
+
+	if !options.Shared {
+		return r.limiter
+	}
+
+	return r.rateLimiters.forHost(host, options)
+}
+
+func (r *Controller) Do(request *http.Request) (*http.Response, error) {
+	limiter := r.rateLimiter(request.URL.Host)
+	if limiter == nil {
+		return r.httpClient.Do(request)
+	}
+
+	waited, waitErr := limiter.Wait(request.Context())
+	RateLimitWaitSeconds.WithLabelValues(r.watcher.Key()).Observe(waited.Seconds())
+
+	if waitErr != nil {
+		return nil, waitErr
+	}
+
+	response, doErr := r.httpClient.Do(request)
+	if doErr == nil {
+		limiter.Observe(response.StatusCode)
+		RateLimitRequestsPerSecond.WithLabelValues(r.watcher.Key()).Set(limiter.Limit())
+	}
+
+	return response, doErr
+}
@@ -0,0 +1,152 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+)
+
+func TestRateLimiter_Observe(t *testing.T) {
+	// given
+	limiter := NewRateLimiter(64, 1)
+
+	// when
+	limiter.Observe(http.StatusTooManyRequests)
+	slowed := limiter.Limit()
+	for range 10 {
+		limiter.Observe(http.StatusTooManyRequests)
+	}
+	floored := limiter.Limit()
+	for range 100 {
+		limiter.Observe(http.StatusOK)
+	}
+	recovered := limiter.Limit()
+
+	// then
+	assert.Equal(t, float64(32), slowed)
+	assert.Equal(t, float64(2), floored)
+	assert.Equal(t, float64(64), recovered)
+}
+
+func TestController_Send_RateLimit(t *testing.T) {
+	// given
+	var (
+		ctx        = context.Background()
+		statusCode = http.StatusTooManyRequests
+		server     = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(statusCode)
+		}))
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Destination: v1alpha1.Destination{
+					URLTemplate: server.URL,
+					Method:      "POST",
+					RateLimit: &v1alpha1.RateLimitOptions{
+						RequestsPerSecond: "20",
+						Burst:             ptr.To(1),
+					},
+				},
+			},
+		}).Compile()
+		controller = NewController(new(client2.MockClient), server.Client(), watcher)
+		obj        = &unstructured.Unstructured{Object: map[string]interface{}{}}
+	)
+	defer server.Close()
+
+	// when
+	start := time.Now()
+	throttledErr := controller.Send(ctx, obj)
+	statusCode = http.StatusOK
+	firstErr := controller.Send(ctx, obj)
+	secondErr := controller.Send(ctx, obj)
+
+	// then
+	assert.ErrorIs(t, throttledErr, ErrUnexpectedStatusCode)
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	assert.Less(t, controller.limiter.Limit(), float64(20))
+}
+
+func TestController_Send_SharedRateLimit(t *testing.T) {
+	// given
+	var (
+		server     = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		newWatcher = func(requestsPerSecond string) *v1alpha1.Watcher {
+			return (&v1alpha1.Watcher{
+				Spec: v1alpha1.WatcherSpec{
+					Destination: v1alpha1.Destination{
+						URLTemplate: server.URL,
+						Method:      "POST",
+						RateLimit: &v1alpha1.RateLimitOptions{
+							RequestsPerSecond: requestsPerSecond,
+							Shared:            true,
+						},
+					},
+				},
+			}).Compile()
+		}
+		rateLimiters  = NewRateLimiters()
+		newController = func(rateLimiters *RateLimiters, requestsPerSecond string) *Controller {
+			return NewController(new(client2.MockClient), server.Client(), newWatcher(requestsPerSecond)).
+				WithRateLimiters(rateLimiters)
+		}
+		firstController  = newController(rateLimiters, "5")
+		secondController = newController(rateLimiters, "5")
+		otherController  = newController(rateLimiters, "10")
+		restarted        = newController(NewRateLimiters(), "5")
+		host             = server.Listener.Addr().String()
+	)
+	defer server.Close()
+
+	// when
+	firstLimiter := firstController.rateLimiter(host)
+	secondLimiter := secondController.rateLimiter(host)
+	otherLimiter := otherController.rateLimiter(host)
+	restartedLimiter := restarted.rateLimiter(host)
+
+	// then
+	assert.Same(t, firstLimiter, secondLimiter)
+	assert.NotSame(t, firstLimiter, otherLimiter)
+	assert.Equal(t, float64(10), otherLimiter.Limit())
+	assert.NotSame(t, firstLimiter, restartedLimiter)
+	assert.NotSame(t, firstController.limiter, firstLimiter)
+	assert.Nil(t, NewController(new(client2.MockClient), server.Client(),
+		(&v1alpha1.Watcher{}).Compile()).rateLimiter(host))
+}
+
+func TestRateLimitOptions_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options v1alpha1.RateLimitOptions
+		valid   bool
+	}{
+		{name: "valid", options: v1alpha1.RateLimitOptions{RequestsPerSecond: "0.5", Burst: ptr.To(2)}, valid: true},
+		{name: "zero", options: v1alpha1.RateLimitOptions{RequestsPerSecond: "0"}},
+		{name: "negative", options: v1alpha1.RateLimitOptions{RequestsPerSecond: "-1"}},
+		{name: "not a number", options: v1alpha1.RateLimitOptions{RequestsPerSecond: "fast"}},
+		{name: "zero burst", options: v1alpha1.RateLimitOptions{RequestsPerSecond: "1", Burst: ptr.To(0)}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// when
+			validateErr := testCase.options.Validate()
+
+			// then
+			if testCase.valid {
+				assert.Nil(t, validateErr)
+			} else {
+				assert.ErrorIs(t, validateErr, v1alpha1.ErrRateLimitInvalid)
+			}
+		})
+	}
+}
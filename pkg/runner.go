@@ -0,0 +1,222 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// sourceRunner runs the controller of a source only while its kind is served by the API server, so the sources of
+// custom resources that aren't installed yet are pending until their CRDs appear, and stopped when they're removed.
+type sourceRunner struct {
+	controller *Controller
+	manager    ctrl.Manager
+	cancel     context.CancelFunc
+	// reported is whether the state of the source is reported in the status of the watcher.
+	reported bool
+	// reachable is whether the remote cluster of the source was reachable when it was last reported.
+	reachable *bool
+}
+
+// keySet is a set of keys shared by the controllers of the sources of a watcher, like the kinds that aren't served.
+type keySet struct {
+	lock sync.Mutex
+	keys map[string]bool
+}
+
+// WithDiscovery makes the sources pending while their kinds aren't served, checking them in every period.
+func (r *Controller) WithDiscovery(discoveryClient discovery.ServerResourcesInterface,
+	period time.Duration,
+) *Controller {
+	r.discovery, r.discoveryPeriod = discoveryClient, period
+
+	return r
+}
+
+func (s *sourceRunner) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.controller.discoveryPeriod)
+	defer ticker.Stop()
+
+	for {
+		if syncErr := s.sync(ctx); syncErr != nil {
+			log.FromContext(ctx).Error(syncErr, "An error occurred while checking the kind of the source.",
+				"watcher", s.controller.name())
+		}
+
+		select {
+		case <-ctx.Done():
+			s.stop(context.WithoutCancel(ctx))
+
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *sourceRunner) sync(ctx context.Context) error {
+	served, servedErr := s.controller.sourceServed()
+
+	reachableErr := s.syncReachable(ctx, servedErr == nil)
+	if servedErr != nil || reachableErr != nil {
+		return errors.Join(servedErr, reachableErr)
+	}
+
+	if s.reported && served == (s.cancel != nil) {
+		return nil
+	}
+
+	if served && s.cancel == nil {
+		if startErr := s.start(ctx); startErr != nil {
+			return startErr
+		}
+	} else if !served {
+		s.stop(ctx)
+	}
+
+	if setErr := s.controller.setSourceServed(ctx, served); setErr != nil {
+		return setErr
+	}
+
+	s.reported = true
+
+	return nil
+}
+
+// syncReachable reports whether the remote cluster of the source can be reached, when it changes.
+func (s *sourceRunner) syncReachable(ctx context.Context, reachable bool) error {
+	if s.controller.source.Cluster == nil || (s.reachable != nil && *s.reachable == reachable) {
+		return nil
+	}
+
+	if setErr := s.controller.setClusterReachable(ctx, reachable); setErr != nil {
+		return setErr
+	}
+
+	s.reachable = &reachable
+
+	return nil
+}
+
+func (s *sourceRunner) start(ctx context.Context) error {
+	reconciler := s.controller
+
+	unmanaged, newErr := controller.NewUnmanaged(reconciler.name(), controller.Options{
+		Reconciler:              reconciler,
+		MaxConcurrentReconciles: reconciler.concurrency(),
+		Logger:                  s.manager.GetLogger(),
+		SkipNameValidation:      ptr.To(true),
+	})
+	if newErr != nil {
+		return newErr
+	}
+
+	for _, watchSource := range reconciler.watchSources(reconciler.sourceCache(s.manager),
+		reconciler.relatedObjects(s.manager)) {
+		if watchErr := unmanaged.Watch(watchSource); watchErr != nil {
+			return watchErr
+		}
+	}
+
+	controllerCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	go func() {
+		if startErr := unmanaged.Start(controllerCtx); startErr != nil {
+			log.FromContext(ctx).Error(startErr, "An error occurred while running the controller of the source.",
+				"watcher", reconciler.name())
+		}
+	}()
+
+	return nil
+}
+
+func (s *sourceRunner) stop(ctx context.Context) {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.cancel = nil
+
+	if removeErr := s.controller.sourceCache(s.manager).RemoveInformer(ctx,
+		s.controller.source.NewObject()); removeErr != nil {
+		log.FromContext(ctx).Error(removeErr, "An error occurred while removing the informer of the source.",
+			"watcher", s.controller.name())
+	}
+}
+
+func (r *Controller) sourceServed() (bool, error) {
+	return kindServed(r.discovery, r.source)
+}
+
+// kindServed returns whether the kind of the source is served by the API server.
+func kindServed(discoveryClient discovery.ServerResourcesInterface, source *v1alpha1.Source) (bool, error) {
+	resourceList, discoveryErr := discoveryClient.ServerResourcesForGroupVersion(source.APIVersion)
+	if apierrors.IsNotFound(discoveryErr) {
+		return false, nil
+	}
+
+	if discoveryErr != nil {
+		return false, discoveryErr
+	}
+
+	return slices.ContainsFunc(resourceList.APIResources, func(resource metav1.APIResource) bool {
+		return resource.Kind == source.Kind && !strings.Contains(resource.Name, "/")
+	}), nil
+}
+
+func (r *Controller) setSourceServed(ctx context.Context, served bool) error {
+	pending := r.pending.set(r.source.GroupVersionKind().String(), !served)
+
+	condition := metav1.Condition{
+		Type:    v1alpha1.ConditionTypeSourcesAvailable,
+		Status:  metav1.ConditionTrue,
+		Reason:  "KindsServed",
+		Message: "The kinds of all sources are served.",
+	}
+
+	if len(pending) > 0 {
+		condition.Status, condition.Reason, condition.Message = metav1.ConditionFalse, "KindsNotServed",
+			"Pending until the kinds are served: "+strings.Join(pending, ", ")
+	}
+
+	return r.SetCondition(ctx, condition)
+}
+
+func newKeySet() *keySet {
+	return &keySet{keys: map[string]bool{}}
+}
+
+// set adds the key to the set or removes it, returning all keys in the set.
+func (k *keySet) set(key string, contains bool) []string {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if contains {
+		k.keys[key] = true
+	} else {
+		delete(k.keys, key)
+	}
+
+	keys := make([]string, 0, len(k.keys))
+	for current := range k.keys {
+		keys = append(keys, current)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	cache2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/cache"
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/manager"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestSourceRunner_Sync(t *testing.T) {
+	// given
+	var (
+		ctx, cancel     = context.WithCancel(context.Background())
+		discoveryClient = &fake.FakeDiscovery{Fake: &clienttesting.Fake{}}
+		watcher         = (&v1alpha1.Watcher{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-watcher"},
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "example.com/v1", Kind: "Widget"},
+			},
+		}).Compile()
+		mockClient            = new(client2.MockClient)
+		mockSubResourceClient = new(client2.MockSubResourceClient)
+		mockManager           = new(manager.MockManager)
+		mockCache             = new(cache2.MockCache)
+		controller            = NewController(mockClient, &http.Client{}, watcher).
+					WithDiscovery(discoveryClient, time.Minute)
+		runner   = &sourceRunner{controller: controller, manager: mockManager}
+		statuses []string
+	)
+	cancel()
+	mockClient.EXPECT().Status().Return(mockSubResourceClient)
+	mockSubResourceClient.EXPECT().Patch(mock.Anything, mock.Anything, client.Apply, mock.Anything,
+		mock.Anything).RunAndReturn(func(ctx context.Context, obj client.Object, patch client.Patch,
+		opts ...client.SubResourcePatchOption,
+	) error {
+		conditions, _, _ := unstructured.NestedSlice(obj.(*unstructured.Unstructured).Object, "status", "conditions")
+		statuses = append(statuses, conditions[0].(map[string]interface{})["status"].(string))
+
+		return nil
+	})
+	mockManager.EXPECT().GetCache().Return(mockCache)
+	mockManager.EXPECT().GetLogger().Return(zap.New())
+	mockCache.EXPECT().GetInformer(mock.Anything, mock.Anything).Return(nil, context.Canceled).Maybe()
+	mockCache.EXPECT().RemoveInformer(mock.Anything, mock.Anything).Return(nil)
+
+	// when
+	pendingErr := runner.sync(ctx)
+	pending := runner.cancel == nil
+	discoveryClient.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "example.com/v1",
+		APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget"}},
+	}}
+	startErr := runner.sync(ctx)
+	started := runner.cancel != nil
+	unchangedErr := runner.sync(ctx)
+	discoveryClient.Resources = nil
+	stopErr := runner.sync(ctx)
+	stopped := runner.cancel == nil
+
+	// then
+	assert.Nil(t, pendingErr)
+	assert.Nil(t, startErr)
+	assert.Nil(t, unchangedErr)
+	assert.Nil(t, stopErr)
+	assert.True(t, pending)
+	assert.True(t, started)
+	assert.True(t, stopped)
+	assert.Equal(t, []string{"False", "True", "False"}, statuses)
+	mockCache.AssertNumberOfCalls(t, "RemoveInformer", 1)
+}
+
+func TestKeySet_Set(t *testing.T) {
+	// given
+	pending := newKeySet()
+
+	// when
+	first := pending.set("example.com/v1, Kind=Widget", true)
+	second := pending.set("example.com/v1, Kind=Gadget", true)
+	served := pending.set("example.com/v1, Kind=Widget", false)
+
+	// then
+	assert.Equal(t, []string{"example.com/v1, Kind=Widget"}, first)
+	assert.Equal(t, []string{"example.com/v1, Kind=Gadget", "example.com/v1, Kind=Widget"}, second)
+	assert.Equal(t, []string{"example.com/v1, Kind=Gadget"}, served)
+}
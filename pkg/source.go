@@ -0,0 +1,270 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var (
+	ErrNoSources           = errors.New("no sources to watch")
+	ErrClusterScopedSource = errors.New("cluster scoped kinds can't be watched in namespaces")
+)
+
+// SourcesResolver resolves the wildcard sources of the watchers again in every period, and calls changed once the
+// kinds that they resolve to change, like when the CRDs of their api versions are installed or removed. It also
+// keeps the watchers whose wildcards don't match any kind yet.
+type SourcesResolver struct {
+	discovery discovery.ServerResourcesInterface
+	clusters  map[string]*RemoteCluster
+	period    time.Duration
+	changed   func()
+	// watchers are the copies of the compiled watchers with wildcard sources, before they're resolved.
+	watchers []v1alpha1.Watcher
+	// kinds are the resolved kinds of the watchers, when they could be resolved.
+	kinds map[int]string
+}
+
+// NewSourcesResolver returns the resolver of the wildcard sources of the compiled watchers, which should be created
+// before their sources are resolved.
+func NewSourcesResolver(discoveryClient discovery.ServerResourcesInterface, clusters map[string]*RemoteCluster,
+	watchers []*v1alpha1.Watcher, period time.Duration, changed func(),
+) *SourcesResolver {
+	resolver := &SourcesResolver{
+		discovery: discoveryClient,
+		clusters:  clusters,
+		period:    period,
+		changed:   changed,
+		kinds:     map[int]string{},
+	}
+
+	for _, watcher := range watchers {
+		if slices.ContainsFunc(watcher.Spec.Compiled.Sources, func(source v1alpha1.Source) bool {
+			return source.IsWildcard()
+		}) {
+			resolver.watchers = append(resolver.watchers, *watcher)
+		}
+	}
+
+	for index := range resolver.watchers {
+		if kinds, resolveErr := resolver.resolve(index); resolveErr == nil {
+			resolver.kinds[index] = kinds
+		}
+	}
+
+	return resolver
+}
+
+func (s *SourcesResolver) Start(ctx context.Context) error {
+	if len(s.watchers) == 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(s.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if s.kindsChanged(ctx) {
+			s.changed()
+
+			return nil
+		}
+	}
+}
+
+func (s *SourcesResolver) kindsChanged(ctx context.Context) bool {
+	for index := range s.watchers {
+		kinds, resolveErr := s.resolve(index)
+		if resolveErr != nil {
+			log.FromContext(ctx).Error(resolveErr, "An error occurred while resolving the sources of the watcher.",
+				"watcher", s.watchers[index].Key())
+
+			continue
+		}
+
+		if previous, resolved := s.kinds[index]; resolved && previous != kinds {
+			log.FromContext(ctx).Info("The kinds of the wildcard sources changed.", "watcher", s.watchers[index].Key(),
+				"kinds", kinds)
+
+			return true
+		}
+
+		s.kinds[index] = kinds
+	}
+
+	return false
+}
+
+// resolve returns the kinds that the wildcard sources of the watcher resolve to, which are empty when none matches.
+func (s *SourcesResolver) resolve(index int) (string, error) {
+	watcher := s.watchers[index]
+
+	resolveErr := ResolveSources(s.discovery, s.clusters, &watcher)
+	if errors.Is(resolveErr, ErrNoSources) {
+		return "", nil
+	}
+
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+
+	keys := make([]string, 0, len(watcher.Spec.Compiled.Sources))
+	for _, source := range watcher.Spec.Compiled.Sources {
+		keys = append(keys, sourceKey(source, source.GroupVersionKind()))
+	}
+
+	slices.Sort(keys)
+
+	return strings.Join(keys, ","), nil
+}
+
+// ResolveSources replaces the wildcard sources of the compiled watcher with a source for each kind of their
+// api version that can be listed and watched, skipping the kinds that are already watched and the api versions
+// that aren't served. The wildcards of remote clusters are resolved with the discovery client of the cluster.
+// The sources watched in namespaces are checked to be namespaced, and wildcards skip the cluster scoped kinds.
+func ResolveSources(discoveryClient discovery.ServerResourcesInterface, clusters map[string]*RemoteCluster,
+	watcher *v1alpha1.Watcher,
+) error {
+	var (
+		sources = make([]v1alpha1.Source, 0, len(watcher.Spec.Compiled.Sources))
+		watched = map[string]bool{}
+	)
+
+	for _, source := range watcher.Spec.Compiled.Sources {
+		if !source.IsWildcard() {
+			watched[sourceKey(source, source.GroupVersionKind())] = true
+		}
+	}
+
+	for _, source := range watcher.Spec.Compiled.Sources {
+		if !source.IsWildcard() && len(source.Namespaces) == 0 {
+			sources = append(sources, source)
+
+			continue
+		}
+
+		sourceDiscovery, discoveryErr := discoveryFor(discoveryClient, clusters, watcher, source)
+		if discoveryErr != nil {
+			return discoveryErr
+		}
+
+		if !source.IsWildcard() {
+			if scopeErr := checkNamespaced(sourceDiscovery, source); scopeErr != nil {
+				return scopeErr
+			}
+
+			sources = append(sources, source)
+
+			continue
+		}
+
+		resolved, resolveErr := resolveWildcard(sourceDiscovery, source, watched)
+		if resolveErr != nil {
+			return resolveErr
+		}
+
+		sources = append(sources, resolved...)
+	}
+
+	if len(sources) == 0 {
+		return fmt.Errorf("%w: %s", ErrNoSources, watcher.Key())
+	}
+
+	watcher.Spec.Compiled.Sources = sources
+
+	return nil
+}
+
+func resolveWildcard(discoveryClient discovery.ServerResourcesInterface, wildcard v1alpha1.Source,
+	watched map[string]bool,
+) ([]v1alpha1.Source, error) {
+	resourceList, discoveryErr := discoveryClient.ServerResourcesForGroupVersion(wildcard.APIVersion)
+	if apierrors.IsNotFound(discoveryErr) {
+		return nil, nil
+	}
+
+	if discoveryErr != nil {
+		return nil, discoveryErr
+	}
+
+	sources := make([]v1alpha1.Source, 0, len(resourceList.APIResources))
+
+	for _, resource := range resourceList.APIResources {
+		source := wildcard
+		source.Kind = resource.Kind
+
+		key := sourceKey(source, source.GroupVersionKind())
+		if watched[key] || strings.Contains(resource.Name, "/") || (len(source.Namespaces) > 0 && !resource.Namespaced) ||
+			!slices.Contains(resource.Verbs, "list") || !slices.Contains(resource.Verbs, "watch") {
+			continue
+		}
+
+		watched[key] = true
+
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+// discoveryFor returns the discovery client of the cluster of the source.
+func discoveryFor(discoveryClient discovery.ServerResourcesInterface, clusters map[string]*RemoteCluster,
+	watcher *v1alpha1.Watcher, source v1alpha1.Source,
+) (discovery.ServerResourcesInterface, error) {
+	key := clusterKey(watcher, &source)
+	if key == "" {
+		return discoveryClient, nil
+	}
+
+	remote := clusters[key]
+	if remote == nil {
+		return nil, fmt.Errorf("%w: %s", ErrClusterNotFound, key)
+	}
+
+	return remote.discovery, nil
+}
+
+// checkNamespaced returns an error when the kind of the source is cluster scoped, which can't be watched in
+// namespaces. The kinds that aren't served yet are checked once they're served.
+func checkNamespaced(discoveryClient discovery.ServerResourcesInterface, source v1alpha1.Source) error {
+	resourceList, discoveryErr := discoveryClient.ServerResourcesForGroupVersion(source.APIVersion)
+	if apierrors.IsNotFound(discoveryErr) {
+		return nil
+	}
+
+	if discoveryErr != nil {
+		return discoveryErr
+	}
+
+	for _, resource := range resourceList.APIResources {
+		if resource.Kind == source.Kind && !strings.Contains(resource.Name, "/") && !resource.Namespaced {
+			return fmt.Errorf("%w: %s", ErrClusterScopedSource, source.GroupVersionKind().GroupKind())
+		}
+	}
+
+	return nil
+}
+
+// sourceKey identifies the kind of the source in its cluster.
+func sourceKey(source v1alpha1.Source, gvk schema.GroupVersionKind) string {
+	if source.Cluster == nil {
+		return gvk.String()
+	}
+
+	return source.Cluster.KubeConfig.String() + "/" + gvk.String()
+}
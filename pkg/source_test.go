@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+)
+
+func TestResolveSources(t *testing.T) {
+	// given
+	var (
+		discoveryClient = &fake.FakeDiscovery{Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "deployments", Kind: "Deployment", Verbs: []string{"get", "list", "watch"}},
+					{Name: "deployments/status", Kind: "Deployment", Verbs: []string{"get", "list", "watch"}},
+					{Name: "statefulsets", Kind: "StatefulSet", Verbs: []string{"get", "list", "watch"}},
+					{Name: "controllerrevisions", Kind: "ControllerRevision", Verbs: []string{"get", "list"}},
+				},
+			}},
+		}}
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Sources: []v1alpha1.Source{
+					{APIVersion: "v1", Kind: "Secret"},
+					{APIVersion: "apps/v1", Kind: v1alpha1.SourceKindAll, Concurrency: ptr.To(2)},
+					{APIVersion: "apps/v1", Kind: "StatefulSet"},
+				},
+			},
+		}).Compile()
+	)
+
+	// when
+	resolveErr := ResolveSources(discoveryClient, nil, watcher)
+
+	// then
+	assert.Nil(t, resolveErr)
+	assert.Len(t, watcher.Spec.Compiled.Sources, 3)
+	assert.Equal(t, "Secret", watcher.Spec.Compiled.Sources[0].Kind)
+	assert.Equal(t, "Deployment", watcher.Spec.Compiled.Sources[1].Kind)
+	assert.Equal(t, 2, watcher.Spec.Compiled.Sources[1].GetConcurrency())
+	assert.Equal(t, "StatefulSet", watcher.Spec.Compiled.Sources[2].Kind)
+	assert.Equal(t, 1, watcher.Spec.Compiled.Sources[2].GetConcurrency())
+}
+
+func TestResolveSources_NoSources(t *testing.T) {
+	// given
+	var (
+		discoveryClient = &fake.FakeDiscovery{Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{{GroupVersion: "example.com/v1"}},
+		}}
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "example.com/v1", Kind: v1alpha1.SourceKindAll},
+			},
+		}).Compile()
+	)
+
+	// when
+	resolveErr := ResolveSources(discoveryClient, nil, watcher)
+
+	// then
+	assert.ErrorIs(t, resolveErr, ErrNoSources)
+}
+
+func TestResolveSources_Namespaced(t *testing.T) {
+	// given
+	var (
+		discoveryClient = &fake.FakeDiscovery{Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{
+					{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"list", "watch"}},
+					{Name: "namespaces", Kind: "Namespace", Verbs: []string{"list", "watch"}},
+				},
+			}},
+		}}
+		wildcardWatcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "v1", Kind: v1alpha1.SourceKindAll, Namespaces: []string{"team-a"}},
+			},
+		}).Compile()
+		clusterScopedWatcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "v1", Kind: "Namespace", Namespaces: []string{"team-a"}},
+			},
+		}).Compile()
+	)
+
+	// when
+	wildcardErr := ResolveSources(discoveryClient, nil, wildcardWatcher)
+	clusterScopedErr := ResolveSources(discoveryClient, nil, clusterScopedWatcher)
+
+	// then
+	assert.Nil(t, wildcardErr)
+	assert.Len(t, wildcardWatcher.Spec.Compiled.Sources, 1)
+	assert.Equal(t, "ConfigMap", wildcardWatcher.Spec.Compiled.Sources[0].Kind)
+	assert.ErrorIs(t, clusterScopedErr, ErrClusterScopedSource)
+}
+
+func TestSourcesResolver_KindsChanged(t *testing.T) {
+	// given
+	var (
+		ctx             = context.Background()
+		discoveryClient = &fake.FakeDiscovery{Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{{GroupVersion: "example.com/v1"}},
+		}}
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "example.com/v1", Kind: v1alpha1.SourceKindAll},
+			},
+		}).Compile()
+		singleKindWatcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{Source: v1alpha1.Source{APIVersion: "v1", Kind: "Secret"}},
+		}).Compile()
+		resolver = NewSourcesResolver(discoveryClient, nil, []*v1alpha1.Watcher{watcher, singleKindWatcher},
+			time.Minute, func() {})
+	)
+
+	// when
+	unchanged := resolver.kindsChanged(ctx)
+
+	discoveryClient.Resources[0].APIResources = []metav1.APIResource{
+		{Name: "widgets", Kind: "Widget", Verbs: []string{"list", "watch"}},
+	}
+	installed := resolver.kindsChanged(ctx)
+
+	// then
+	assert.Len(t, resolver.watchers, 1)
+	assert.False(t, unchanged)
+	assert.True(t, installed)
+	assert.Nil(t, ResolveSources(discoveryClient, nil, watcher))
+	assert.Equal(t, "Widget", watcher.Spec.Compiled.Sources[0].Kind)
+	assert.True(t, resolver.watchers[0].Spec.Compiled.Sources[0].IsWildcard())
+}
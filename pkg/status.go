@@ -0,0 +1,121 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	fieldOwnerPrefix = "watchtower"
+)
+
+func (r *Controller) SetCondition(ctx context.Context, condition metav1.Condition) error {
+	return setCondition(ctx, r.statusClient, r.watcher, condition)
+}
+
+// SetLoaded reports whether the watcher could be loaded and compiled in its status, unless it's already reported.
+func SetLoaded(ctx context.Context, statusClient client.Client, watcher *v1alpha1.Watcher, loadErr error) error {
+	condition := metav1.Condition{
+		Type:    v1alpha1.ConditionTypeLoaded,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Loaded",
+		Message: "The watcher is loaded.",
+	}
+
+	if loadErr != nil {
+		condition.Status, condition.Reason, condition.Message = metav1.ConditionFalse, "LoadFailed",
+			"The watcher is skipped: "+loadErr.Error()
+	}
+
+	if current := meta.FindStatusCondition(watcher.Status.Conditions, condition.Type); current != nil &&
+		current.Status == condition.Status && current.Message == condition.Message &&
+		current.ObservedGeneration == watcher.GetGeneration() {
+		return nil
+	}
+
+	return setCondition(ctx, statusClient, watcher, condition)
+}
+
+func setCondition(ctx context.Context, statusClient client.Client, watcherToPatch *v1alpha1.Watcher,
+	condition metav1.Condition,
+) error {
+	condition.ObservedGeneration = watcherToPatch.GetGeneration()
+	condition.LastTransitionTime = metav1.Now()
+
+	conditionObject, convertErr := runtime.DefaultUnstructuredConverter.ToUnstructured(&condition)
+	if convertErr != nil {
+		return convertErr
+	}
+
+	watcher := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{conditionObject},
+		},
+	}}
+	watcher.SetGroupVersionKind(v1alpha1.GroupVersion.WithKind(watcherToPatch.StatusKind()))
+	watcher.SetNamespace(watcherToPatch.GetNamespace())
+	watcher.SetName(watcherToPatch.GetName())
+
+	return statusClient.Status().Patch(ctx, watcher, client.Apply, client.ForceOwnership,
+		client.FieldOwner(fmt.Sprintf("%s-%s", fieldOwnerPrefix, condition.Type)))
+}
+
+// onCircuitStateChange replaces the state waiting to be reported with the latest one, so the circuit breaker doesn't
+// wait for the status to be updated.
+func (r *Controller) onCircuitStateChange(state CircuitState) {
+	CircuitBreakerState.WithLabelValues(r.watcher.Key()).Set(circuitStateValues[state])
+
+	select {
+	case <-r.circuitStates:
+	default:
+	}
+
+	select {
+	case r.circuitStates <- state:
+	default:
+	}
+}
+
+// reportCircuitStates reports the states of the circuit breaker in the status of the watcher until the context ends.
+func (r *Controller) reportCircuitStates(ctx context.Context) error {
+	for {
+		select {
+		case state := <-r.circuitStates:
+			if setErr := r.SetCondition(ctx, circuitCondition(state)); setErr != nil {
+				log.FromContext(ctx).WithValues("watcher", r.watcher.Key()).
+					Error(setErr, "An error occurred while updating the watcher status.")
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func circuitCondition(state CircuitState) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    v1alpha1.ConditionTypeDestinationAvailable,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Circuit" + string(state),
+		Message: "The destination is responding successfully.",
+	}
+
+	switch state {
+	case CircuitStateOpen:
+		condition.Status, condition.Message = metav1.ConditionFalse,
+			"The destination keeps failing, objects are requeued until it's probed again."
+	case CircuitStateHalfOpen:
+		condition.Status, condition.Message = metav1.ConditionUnknown,
+			"The destination is being probed after failures."
+	case CircuitStateClosed:
+	}
+
+	return condition
+}
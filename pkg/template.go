@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"encoding/json"
+	"maps"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	TemplateKeyTransition = "Transition"
+	TemplateKeyOldObject  = "OldObject"
+	TemplateKeyPatch      = "Patch"
+	TemplateKeyCluster    = "cluster"
+	TemplateKeyValues     = "Values"
+
+	TemplateKeyOccurrences    = "Occurrences"
+	TemplateKeyInvolvedObject = "InvolvedObject"
+)
+
+var templateKeys = []string{
+	TemplateKeyTransition, TemplateKeyOldObject, TemplateKeyPatch, TemplateKeyCluster, TemplateKeyValues,
+	TemplateKeyOccurrences, TemplateKeyInvolvedObject,
+}
+
+// TemplateData is the object with the additional keys available in templates, which are left out of its JSON.
+type TemplateData map[string]any
+
+func (d TemplateData) MarshalJSON() ([]byte, error) {
+	object := maps.Clone(map[string]any(d))
+	for _, key := range templateKeys {
+		delete(object, key)
+	}
+
+	return json.Marshal(object)
+}
+
+func (r *Controller) TemplateData(obj *unstructured.Unstructured) TemplateData {
+	data := TemplateData(maps.Clone(obj.Object))
+
+	if r.source.Cluster != nil {
+		data[TemplateKeyCluster] = r.source.Cluster.GetName()
+	}
+
+	if values := r.values.Get(); values != nil {
+		data[TemplateKeyValues] = values
+	}
+
+	objectEvent := r.lastEvent(client.ObjectKeyFromObject(obj))
+	if objectEvent == nil {
+		return data
+	}
+
+	if objectEvent.Transition != nil {
+		data[TemplateKeyTransition] = objectEvent.Transition
+	}
+
+	if objectEvent.OldObject != nil {
+		data[TemplateKeyOldObject] = objectEvent.OldObject.Object
+	}
+
+	return data
+}
+
+func (r *Controller) TemplateExecute(template *template.Template, obj *unstructured.Unstructured) ([]byte, error) {
+	return r.executeTemplate(template, r.TemplateData(obj))
+}
@@ -0,0 +1,192 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Values are the values of a watcher that are available in templates as .Values, which are reloaded in place when
+// its config maps are changed.
+type Values struct {
+	lock   sync.RWMutex
+	values map[string]string
+}
+
+// ValuesLoader loads the values of the watchers from the config maps in their valuesFrom, and watches the config maps
+// to reload the values in place, so the watchers aren't restarted when the config maps are changed.
+type ValuesLoader struct {
+	reader   client.Reader
+	cache    cache.Cache
+	watchers []*v1alpha1.Watcher
+	values   map[string]*Values
+}
+
+func NewValues(values map[string]string) *Values {
+	return &Values{values: values}
+}
+
+func (v *Values) Get() map[string]string {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	return v.values
+}
+
+func (v *Values) set(values map[string]string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.values = values
+}
+
+// NewValuesLoader loads the values of the compiled watchers with the reader, and creates the cache of the config maps
+// in the namespaces of their config maps, which the values are reloaded from once it's started.
+func NewValuesLoader(ctx context.Context, reader client.Reader, mgr ctrl.Manager,
+	watchers []*v1alpha1.Watcher,
+) (*ValuesLoader, error) {
+	var (
+		loader     = newValuesLoader(reader, watchers)
+		namespaces = map[string]cache.Config{}
+	)
+
+	for _, watcher := range watchers {
+		loader.load(ctx, watcher)
+
+		for _, selector := range watcher.Spec.ValuesFrom.ConfigMaps {
+			namespaces[selector.Namespace] = cache.Config{}
+		}
+	}
+
+	if len(namespaces) == 0 {
+		return loader, nil
+	}
+
+	valuesCache, newErr := cache.New(mgr.GetConfig(), cache.Options{
+		HTTPClient:        mgr.GetHTTPClient(),
+		Scheme:            mgr.GetScheme(),
+		Mapper:            mgr.GetRESTMapper(),
+		DefaultNamespaces: namespaces,
+	})
+	if newErr != nil {
+		return nil, newErr
+	}
+
+	loader.reader, loader.cache = valuesCache, valuesCache
+
+	return loader, nil
+}
+
+func newValuesLoader(reader client.Reader, watchers []*v1alpha1.Watcher) *ValuesLoader {
+	loader := &ValuesLoader{reader: reader, watchers: watchers, values: map[string]*Values{}}
+	for _, watcher := range watchers {
+		loader.values[watcher.Key()] = NewValues(watcher.Spec.Values)
+	}
+
+	return loader
+}
+
+// WithValues makes the templates render the values, which are reloaded in place when the config maps are changed.
+func (r *Controller) WithValues(values *Values) *Controller {
+	r.values = values
+
+	return r
+}
+
+// ValuesOf returns the values of the watcher.
+func (l *ValuesLoader) ValuesOf(watcher *v1alpha1.Watcher) *Values {
+	return l.values[watcher.Key()]
+}
+
+func (l *ValuesLoader) Start(ctx context.Context) error {
+	if l.cache == nil {
+		return nil
+	}
+
+	informer, informerErr := l.cache.GetInformer(ctx, &v1.ConfigMap{}, cache.BlockUntilSynced(false))
+	if informerErr != nil {
+		return informerErr
+	}
+
+	if _, addErr := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { l.reload(ctx, obj) },
+		UpdateFunc: func(_, obj any) { l.reload(ctx, obj) },
+		DeleteFunc: func(obj any) { l.reload(ctx, obj) },
+	}); addErr != nil {
+		return addErr
+	}
+
+	return l.cache.Start(ctx)
+}
+
+// reload loads the values of the watchers referencing the config map again.
+func (l *ValuesLoader) reload(ctx context.Context, obj any) {
+	if tombstone, isTombstone := obj.(toolscache.DeletedFinalStateUnknown); isTombstone {
+		obj = tombstone.Obj
+	}
+
+	configMap, isConfigMap := obj.(*v1.ConfigMap)
+	if !isConfigMap {
+		return
+	}
+
+	for _, watcher := range l.watchers {
+		if slices.Contains(watcher.Spec.ValuesFrom.ConfigMaps, v1alpha1.ConfigMapSelector{
+			Name: configMap.Name, Namespace: configMap.Namespace,
+		}) {
+			l.load(ctx, watcher)
+		}
+	}
+}
+
+func (l *ValuesLoader) load(ctx context.Context, watcher *v1alpha1.Watcher) {
+	if len(watcher.Spec.ValuesFrom.ConfigMaps) == 0 {
+		return
+	}
+
+	values, loadErr := LoadValues(ctx, l.reader, watcher)
+	if loadErr != nil {
+		log.FromContext(ctx).Error(loadErr, "An error occurred while loading the values of the watcher.",
+			"watcher", watcher.Key())
+	}
+
+	l.values[watcher.Key()].set(values)
+}
+
+// LoadValues merges the data of the config maps in valuesFrom and the inline values of the watcher. The config maps
+// that aren't found are left out, and the ones that can't be read are left out with their errors joined.
+func LoadValues(ctx context.Context, reader client.Reader, watcher *v1alpha1.Watcher) (map[string]string, error) {
+	var (
+		values = map[string]string{}
+		errs   []error
+	)
+
+	for _, selector := range watcher.Spec.ValuesFrom.ConfigMaps {
+		var configMap v1.ConfigMap
+		if getErr := reader.Get(ctx, client.ObjectKey{
+			Name: selector.Name, Namespace: selector.Namespace,
+		}, &configMap); getErr != nil {
+			if client.IgnoreNotFound(getErr) != nil {
+				errs = append(errs, getErr)
+			}
+
+			continue
+		}
+
+		maps.Copy(values, configMap.Data)
+	}
+
+	maps.Copy(values, watcher.Spec.Values)
+
+	return values, errors.Join(errs...)
+}
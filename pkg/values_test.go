@@ -0,0 +1,167 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	client2 "github.com/nccloud/watchtower/mocks/sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/nccloud/watchtower/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestLoadValues(t *testing.T) {
+	// given
+	var (
+		ctx        = context.Background()
+		mockClient = new(client2.MockClient)
+		watcher    = &v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				ValuesFrom: v1alpha1.ValuesFrom{ConfigMaps: []v1alpha1.ConfigMapSelector{
+					{Name: "shared", Namespace: "watchtower"},
+					{Name: "team", Namespace: "watchtower"},
+				}},
+				Values: map[string]string{"channel": "#alerts"},
+			},
+		}
+		data = map[string]map[string]string{
+			"shared": {"endpoint": "https://shared.example.com", "channel": "#general"},
+			"team":   {"endpoint": "https://team.example.com"},
+		}
+	)
+	mockClient.EXPECT().Get(ctx, mock.Anything, mock.AnythingOfType("*v1.ConfigMap")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			obj.(*v1.ConfigMap).Data = data[key.Name]
+
+			return nil
+		})
+
+	// when
+	values, loadErr := LoadValues(ctx, mockClient, watcher)
+
+	// then
+	assert.Nil(t, loadErr)
+	assert.Equal(t, map[string]string{"endpoint": "https://team.example.com", "channel": "#alerts"}, values)
+	assert.Equal(t, map[string]string{"channel": "#alerts"}, watcher.Spec.Values)
+}
+
+func TestLoadValues_NotFound(t *testing.T) {
+	// given
+	var (
+		ctx        = context.Background()
+		mockClient = new(client2.MockClient)
+		watcher    = &v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				ValuesFrom: v1alpha1.ValuesFrom{ConfigMaps: []v1alpha1.ConfigMapSelector{
+					{Name: "missing", Namespace: "watchtower"},
+					{Name: "forbidden", Namespace: "watchtower"},
+				}},
+				Values: map[string]string{"channel": "#alerts"},
+			},
+		}
+		forbiddenErr = apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "forbidden", nil)
+	)
+	mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "missing", Namespace: "watchtower"},
+		mock.AnythingOfType("*v1.ConfigMap")).Return(
+		apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "missing"))
+	mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "forbidden", Namespace: "watchtower"},
+		mock.AnythingOfType("*v1.ConfigMap")).Return(forbiddenErr)
+
+	// when
+	values, loadErr := LoadValues(ctx, mockClient, watcher)
+
+	// then
+	assert.ErrorIs(t, loadErr, forbiddenErr)
+	assert.Equal(t, map[string]string{"channel": "#alerts"}, values)
+}
+
+func TestValuesLoader_Reload(t *testing.T) {
+	// given
+	var (
+		ctx        = context.Background()
+		mockClient = new(client2.MockClient)
+		watcher    = &v1alpha1.Watcher{
+			ObjectMeta: metav1.ObjectMeta{Name: "watcher"},
+			Spec: v1alpha1.WatcherSpec{
+				ValuesFrom: v1alpha1.ValuesFrom{ConfigMaps: []v1alpha1.ConfigMapSelector{
+					{Name: "shared", Namespace: "watchtower"},
+				}},
+				Values: map[string]string{"channel": "#alerts"},
+			},
+		}
+		other = &v1alpha1.Watcher{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Spec:       v1alpha1.WatcherSpec{Values: map[string]string{"channel": "#other"}},
+		}
+		loader    = newValuesLoader(mockClient, []*v1alpha1.Watcher{watcher, other})
+		configMap = &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "watchtower"}}
+		data      = map[string]string{"endpoint": "https://shared.example.com"}
+	)
+	mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "shared", Namespace: "watchtower"},
+		mock.AnythingOfType("*v1.ConfigMap")).RunAndReturn(
+		func(ctx context.Context, key types.NamespacedName, obj client.Object, opts ...client.GetOption) error {
+			if data == nil {
+				return apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, key.Name)
+			}
+
+			obj.(*v1.ConfigMap).Data = data
+
+			return nil
+		})
+
+	// when
+	initial := loader.ValuesOf(watcher).Get()
+	loader.reload(ctx, configMap)
+	updated := loader.ValuesOf(watcher).Get()
+	loader.reload(ctx, &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "watchtower"}})
+	data = nil
+	loader.reload(ctx, toolscache.DeletedFinalStateUnknown{Obj: configMap})
+	deleted := loader.ValuesOf(watcher).Get()
+
+	// then
+	assert.Equal(t, map[string]string{"channel": "#alerts"}, initial)
+	assert.Equal(t, map[string]string{"endpoint": "https://shared.example.com", "channel": "#alerts"}, updated)
+	assert.Equal(t, map[string]string{"channel": "#alerts"}, deleted)
+	assert.Equal(t, map[string]string{"channel": "#other"}, loader.ValuesOf(other).Get())
+	mockClient.AssertNumberOfCalls(t, "Get", 2)
+}
+
+func TestController_TemplateExecute_Values(t *testing.T) {
+	// given
+	var (
+		watcher = (&v1alpha1.Watcher{
+			Spec: v1alpha1.WatcherSpec{
+				Source: v1alpha1.Source{APIVersion: "v1", Kind: "Secret"},
+				Values: map[string]string{"channel": "#alerts"},
+				Destination: v1alpha1.Destination{
+					BodyTemplate: `{{ .Values.channel }}/{{ .metadata.name }}`,
+				},
+			},
+		}).Compile()
+		controller = NewController(new(client2.MockClient), &http.Client{}, watcher)
+		secret     = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "my-secret", "namespace": "default"},
+		}}
+	)
+
+	// when
+	body, executeErr := controller.TemplateExecute(watcher.Spec.Destination.Compiled.BodyTemplate, secret)
+	object, marshalErr := controller.TemplateData(secret).MarshalJSON()
+
+	// then
+	assert.Nil(t, executeErr)
+	assert.Equal(t, "#alerts/my-secret", string(body))
+	assert.Nil(t, marshalErr)
+	assert.NotContains(t, string(object), "#alerts")
+}